@@ -0,0 +1,157 @@
+package money
+
+import "sort"
+
+// TriangularInconsistency is a cycle of three exchanges A→B→C→A whose exchange
+// rates multiplied together are higher than 1: exchanging money along the
+// cycle gives back more money than the initial amount.
+type TriangularInconsistency struct {
+	// Currency ISO codes of the cycle, for example [EUR USD JPY EUR].
+	Path []string
+	// Product of the exchange rates along the cycle.
+	Product Decimal
+}
+
+// NonReciprocalPair is a pair of currencies whose exchange rates A→B and B→A
+// are not reciprocal, that is A→B * B→A is not 1.
+type NonReciprocalPair struct {
+	// ISO code of the currency A.
+	From string
+	// ISO code of the currency B.
+	To string
+	// Exchange rate A→B.
+	Rate Decimal
+	// Exchange rate B→A.
+	InverseRate Decimal
+	// Product A→B * B→A.
+	Product Decimal
+}
+
+// CoverageGap is an exchange rate missing in the exchange rates table.
+type CoverageGap struct {
+	// ISO code of the currency to convert from.
+	From string
+	// ISO code of the currency to convert to.
+	To string
+}
+
+// FindTriangularInconsistencies returns the cycles A→B→C→A of the table whose
+// product exceeds 1 + tolerance, sorted by decreasing product. Each cycle is
+// reported once, starting from the currency with the lowest ISO code. Only
+// positive exchange rates are considered.
+func (table ExchangeRatesTable) FindTriangularInconsistencies(tolerance float64) []TriangularInconsistency {
+	codes, rates := table.positiveFloatRates()
+	threshold := NewDecimal(1, 1).Add(NewDecimalFromFloat(tolerance))
+	// Cycles are screened with float64, with a margin for rounding errors,
+	// and confirmed with the exact exchange rates
+	floatThreshold := (1 + tolerance) * (1 - 1e-9)
+
+	inconsistencies := make([]TriangularInconsistency, 0)
+	for _, a := range codes {
+		for b, ab := range rates[a] {
+			if b <= a {
+				continue
+			}
+			for c, bc := range rates[b] {
+				if c <= a || c == b {
+					continue
+				}
+				ca, found := rates[c][a]
+				if !found || ab*bc*ca <= floatThreshold {
+					continue
+				}
+				product := table[a][b].Mul(table[b][c]).Mul(table[c][a])
+				if product.Cmp(threshold) > 0 {
+					inconsistencies = append(inconsistencies, TriangularInconsistency{Path: []string{a, b, c, a}, Product: product})
+				}
+			}
+		}
+	}
+	sort.Slice(inconsistencies, func(i, j int) bool {
+		cmp := inconsistencies[i].Product.Cmp(inconsistencies[j].Product)
+		if cmp != 0 {
+			return cmp > 0
+		}
+		return lessPath(inconsistencies[i].Path, inconsistencies[j].Path)
+	})
+	return inconsistencies
+}
+
+// FindNonReciprocalPairs returns the pairs of currencies with both exchange
+// rates A→B and B→A in the table whose product differs from 1 more than
+// tolerance, sorted by currency ISO codes. Each pair is reported once, with
+// From < To.
+func (table ExchangeRatesTable) FindNonReciprocalPairs(tolerance float64) []NonReciprocalPair {
+	one := NewDecimal(1, 1)
+	maxDistance := NewDecimalFromFloat(tolerance)
+	pairs := make([]NonReciprocalPair, 0)
+	for fromCurrencyIsoCode, rates := range table {
+		for toCurrencyIsoCode, rate := range rates {
+			if fromCurrencyIsoCode >= toCurrencyIsoCode || rate.Sign() <= 0 {
+				continue
+			}
+			inverseRate := table[toCurrencyIsoCode][fromCurrencyIsoCode]
+			if inverseRate.Sign() <= 0 {
+				continue
+			}
+			product := rate.Mul(inverseRate)
+			if absDecimal(product.Sub(one)).Cmp(maxDistance) > 0 {
+				pairs = append(pairs, NonReciprocalPair{From: fromCurrencyIsoCode, To: toCurrencyIsoCode, Rate: rate, InverseRate: inverseRate, Product: product})
+			}
+		}
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		if pairs[i].From != pairs[j].From {
+			return pairs[i].From < pairs[j].From
+		}
+		return pairs[i].To < pairs[j].To
+	})
+	return pairs
+}
+
+// FindCoverageGaps returns the exchange rates between the currencies with ISO
+// codes currencyIsoCodes that are missing in the table, or that are not
+// positive, in the order of currencyIsoCodes.
+func (table ExchangeRatesTable) FindCoverageGaps(currencyIsoCodes []string) []CoverageGap {
+	gaps := make([]CoverageGap, 0)
+	for _, fromCurrencyIsoCode := range currencyIsoCodes {
+		for _, toCurrencyIsoCode := range currencyIsoCodes {
+			if fromCurrencyIsoCode == toCurrencyIsoCode {
+				continue
+			}
+			if table[fromCurrencyIsoCode][toCurrencyIsoCode].Sign() <= 0 {
+				gaps = append(gaps, CoverageGap{From: fromCurrencyIsoCode, To: toCurrencyIsoCode})
+			}
+		}
+	}
+	return gaps
+}
+
+// Private functions
+
+// positiveFloatRates returns the sorted currency ISO codes of the table and the
+// float64 approximation of its positive exchange rates.
+func (table ExchangeRatesTable) positiveFloatRates() ([]string, map[string]map[string]float64) {
+	codes := make([]string, 0, len(table))
+	rates := make(map[string]map[string]float64, len(table))
+	for fromCurrencyIsoCode, fromRates := range table {
+		codes = append(codes, fromCurrencyIsoCode)
+		rates[fromCurrencyIsoCode] = make(map[string]float64, len(fromRates))
+		for toCurrencyIsoCode, rate := range fromRates {
+			if fromCurrencyIsoCode != toCurrencyIsoCode && rate.Sign() > 0 {
+				rates[fromCurrencyIsoCode][toCurrencyIsoCode] = rate.Float64()
+			}
+		}
+	}
+	sort.Strings(codes)
+	return codes, rates
+}
+
+func lessPath(path1, path2 []string) bool {
+	for i := 0; i < len(path1) && i < len(path2); i++ {
+		if path1[i] != path2[i] {
+			return path1[i] < path2[i]
+		}
+	}
+	return len(path1) < len(path2)
+}
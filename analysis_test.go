@@ -0,0 +1,87 @@
+package money_test
+
+import (
+	"testing"
+
+	"github.com/pioz/money"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFindTriangularInconsistencies(t *testing.T) {
+	table := money.ExchangeRatesTableFromFloat(map[string]map[string]float64{
+		"EUR": {"USD": 1.2, "JPY": 132},
+		"USD": {"EUR": 0.8, "JPY": 110},
+		"JPY": {"EUR": 0.008, "USD": 1.0 / 110},
+	})
+	// EUR→USD→JPY→EUR = 1.2 * 110 * 0.008 = 1.056
+	// EUR→JPY→USD→EUR = 132 / 110 * 0.8 = 0.96
+	inconsistencies := table.FindTriangularInconsistencies(0.01)
+	assert.Equal(t, 1, len(inconsistencies))
+	assert.Equal(t, []string{"EUR", "USD", "JPY", "EUR"}, inconsistencies[0].Path)
+	assert.Equal(t, "1.056", inconsistencies[0].Product.String())
+
+	assert.Equal(t, 0, len(table.FindTriangularInconsistencies(0.06)))
+}
+
+func TestFindTriangularInconsistenciesLargeTable(t *testing.T) {
+	// Cross rates of a single base are consistent
+	usdRates := make(map[string]money.Decimal)
+	for i, currency := range money.AllCurrencies {
+		usdRates[currency.IsoCode] = money.NewDecimal(int64(i+1), 7)
+	}
+	table := make(money.ExchangeRatesTable)
+	for fromIsoCode, fromRate := range usdRates {
+		table[fromIsoCode] = make(money.ExchangeRates)
+		for toIsoCode, toRate := range usdRates {
+			if fromIsoCode != toIsoCode {
+				table[fromIsoCode][toIsoCode] = toRate.Quo(fromRate)
+			}
+		}
+	}
+	assert.Equal(t, 0, len(table.FindTriangularInconsistencies(1e-6)))
+	assert.Equal(t, 0, len(table.FindNonReciprocalPairs(0)))
+
+	table["EUR"]["USD"] = table["EUR"]["USD"].Mul(money.NewDecimal(101, 100))
+	inconsistencies := table.FindTriangularInconsistencies(0.001)
+	// A cycle for each other currency, in the direction EUR→USD
+	assert.Equal(t, len(money.AllCurrencies)-2, len(inconsistencies))
+	for _, inconsistency := range inconsistencies {
+		assert.Equal(t, 0, inconsistency.Product.Cmp(money.NewDecimal(101, 100)))
+	}
+	assert.Equal(t, []money.NonReciprocalPair{{
+		From:        "EUR",
+		To:          "USD",
+		Rate:        table["EUR"]["USD"],
+		InverseRate: table["USD"]["EUR"],
+		Product:     money.NewDecimal(101, 100),
+	}}, table.FindNonReciprocalPairs(0.001))
+}
+
+func TestFindNonReciprocalPairs(t *testing.T) {
+	table := money.ExchangeRatesTableFromFloat(map[string]map[string]float64{
+		"EUR": {"USD": 1.25, "GBP": 0.9},
+		"USD": {"EUR": 0.8, "GBP": 0.7},
+		"GBP": {"EUR": 1.2},
+	})
+	pairs := table.FindNonReciprocalPairs(0.01)
+	assert.Equal(t, 1, len(pairs))
+	assert.Equal(t, "EUR", pairs[0].From)
+	assert.Equal(t, "GBP", pairs[0].To)
+	assert.Equal(t, "1.08", pairs[0].Product.String())
+
+	assert.Equal(t, 0, len(table.FindNonReciprocalPairs(0.1)))
+}
+
+func TestFindCoverageGaps(t *testing.T) {
+	table := money.ExchangeRatesTableFromFloat(map[string]map[string]float64{
+		"EUR": {"USD": 1.25, "GBP": 0},
+		"USD": {"EUR": 0.8},
+	})
+	assert.Equal(t, []money.CoverageGap{
+		{From: "EUR", To: "GBP"},
+		{From: "USD", To: "GBP"},
+		{From: "GBP", To: "EUR"},
+		{From: "GBP", To: "USD"},
+	}, table.FindCoverageGaps([]string{"EUR", "USD", "GBP"}))
+	assert.Equal(t, []money.CoverageGap{}, table.FindCoverageGaps([]string{"EUR", "USD"}))
+}
@@ -1,9 +1,9 @@
 package money
 
 import (
-	"fmt"
-	"log"
 	"math"
+	"sync"
+	"time"
 )
 
 // FetchExchangeRatesTableFunc is the signature of the function to fetch an
@@ -20,6 +20,45 @@ type Bank struct {
 	ExchangeRatesTable      ExchangeRatesTable
 	exchangeRatesTableCache ExchangeRatesTableCache
 	fetchExchangeRatesTable FetchExchangeRatesTableFunc
+	// Map by date (see dateKey) of the historical exchange rates tables
+	historicalExchangeRatesTables map[string]ExchangeRatesTable
+	historicalFallbackDays        int
+	pivotCurrencyIsoCode          string
+	shortestPathTriangulation     bool
+	inverseExchangeRates          bool
+	overridePublishedRates        bool
+	// Set of the exchange rates derived as inverse of a published rate
+	derivedExchangeRates map[string]map[string]bool
+	spreads              map[string]map[string]Spread
+	defaultSpread        Spread
+	defaultSpreadBase    string
+	conversionFee        ConversionFee
+	roundingMode         RoundingMode
+	// Base currency of the compact exchange rates table, empty for the full
+	// table (see SetCompactExchangeRates)
+	baseCurrencyIsoCode string
+	// Source and time of the last update of the exchange rates table
+	exchangeRatesSource    string
+	exchangeRatesUpdatedAt time.Time
+	// True if the bank is a snapshot (see Snapshot)
+	readOnly           bool
+	updateObservers    []ExchangeRatesTableObserver
+	fetchErrorHandlers []FetchErrorHandler
+	logger             Logger
+	providerName       string
+	// Maximum age of the exchange rates table, zero means no limit
+	maxStaleness time.Duration
+	// Maximum age of the cached exchange rates table, zero means no limit
+	cacheMaxAge time.Duration
+	// True after the first successful fetch of the exchange rates table
+	fetched bool
+	// True if the bank has been started with WithNonBlockingStartup
+	nonBlockingStartup bool
+	validationRules    *ValidationRules
+	// Closed by Close to stop the background refresh
+	stopRefresh chan struct{}
+	closeOnce   sync.Once
+	mu          sync.RWMutex
 }
 
 // The DefaultBank supports all currencies (money.AllCurrencies), but it is
@@ -33,67 +72,91 @@ var DefaultBank, _ = NewBank(AllCurrencies, nil, nil)
 // routine in background updates the table using fetch. Returns an error if
 // fetch returns error.
 func NewBank(currencies []Currency, fetch FetchExchangeRatesTableFunc, cache ExchangeRatesTableCache) (*Bank, error) {
-	bank := &Bank{
-		Currencies:              make(map[string]Currency),
-		ExchangeRatesTable:      make(ExchangeRatesTable),
-		exchangeRatesTableCache: cache,
-		fetchExchangeRatesTable: fetch,
-	}
-	for _, fromCurrency := range currencies {
-		bank.Currencies[fromCurrency.IsoCode] = fromCurrency
-		bank.ExchangeRatesTable[fromCurrency.IsoCode] = make(ExchangeRates)
-	}
-
+	bank := newBank(currencies, fetch, cache)
 	return bank, bank.UpdateExchangeRatesTable()
 }
 
 // NewBankFromStaticExchangeRatesTable is a conveniently function to create a
-// new bank that use a static exchange rates table.
+// new bank that use a static exchange rates table. The table can be loaded
+// from a JSON file embedded in the program with ExchangeRatesTableFromJSON.
 func NewBankFromStaticExchangeRatesTable(currencies []Currency, table ExchangeRatesTable) (*Bank, error) {
 	return NewBank(currencies, func() (ExchangeRatesTable, error) {
 		return table, nil
 	}, nil)
 }
 
-// GetExchangeRate returns the exchange rate to convert the currency with ISO
-// code fromCurrencyIsoCode to the currency with ISO code toCurrencyIsoCode.
-// Returns an error if the bank does not support one of the two currencies or if
-// it does not support the exchange between the two currencies, meaning that the
-// exchange rates table does not have the exchange rate.
-func (bank *Bank) GetExchangeRate(fromCurrencyIsoCode, toCurrencyIsoCode string) (float64, error) {
-	if fromCurrencyIsoCode == toCurrencyIsoCode {
-		return 1.0, nil
+// newBank creates a new bank without updating its exchange rates table.
+func newBank(currencies []Currency, fetch FetchExchangeRatesTableFunc, cache ExchangeRatesTableCache) *Bank {
+	bank := &Bank{
+		Currencies:                    make(map[string]Currency),
+		ExchangeRatesTable:            make(ExchangeRatesTable),
+		exchangeRatesTableCache:       cache,
+		fetchExchangeRatesTable:       fetch,
+		historicalExchangeRatesTables: make(map[string]ExchangeRatesTable),
+		derivedExchangeRates:          make(map[string]map[string]bool),
+		spreads:                       make(map[string]map[string]Spread),
+		logger:                        stdLogger{},
 	}
-	exchangeRates := bank.ExchangeRatesTable[fromCurrencyIsoCode]
-	rate := exchangeRates[toCurrencyIsoCode]
-	if rate == 0.0 {
-		return 0.0, fmt.Errorf("bank does not support exchange from %s to %s", fromCurrencyIsoCode, toCurrencyIsoCode)
+	for _, fromCurrency := range currencies {
+		bank.Currencies[fromCurrency.IsoCode] = fromCurrency
+		bank.ExchangeRatesTable[fromCurrency.IsoCode] = make(ExchangeRates)
 	}
-	return rate, nil
+	return bank
+}
+
+// GetExchangeRate returns the exchange rate to convert the currency with ISO
+// code fromCurrencyIsoCode to the currency with ISO code toCurrencyIsoCode. It
+// is a conveniently wrapper of GetDecimalExchangeRate that returns the nearest
+// float64 of the exchange rate.
+func (bank *Bank) GetExchangeRate(fromCurrencyIsoCode, toCurrencyIsoCode string) (float64, error) {
+	rate, err := bank.GetDecimalExchangeRate(fromCurrencyIsoCode, toCurrencyIsoCode)
+	return rate.Float64(), err
+}
+
+// GetDecimalExchangeRate returns the exact exchange rate to convert the
+// currency with ISO code fromCurrencyIsoCode to the currency with ISO code
+// toCurrencyIsoCode. Returns an error if the bank does not support one of the
+// two currencies or if it does not support the exchange between the two
+// currencies, meaning that the exchange rates table does not have the
+// exchange rate and it can not be derived by triangulation (see
+// GetExchangeRatePath).
+func (bank *Bank) GetDecimalExchangeRate(fromCurrencyIsoCode, toCurrencyIsoCode string) (Decimal, error) {
+	rate, _, err := bank.GetExchangeRatePath(fromCurrencyIsoCode, toCurrencyIsoCode)
+	return rate, err
 }
 
 // UpdateExchangeRatesTable updates the bank exchange rates table by calling the
-// fetch function. If fetch is nil, it has no effect. Returns an error if fetch
-// returns error.
+// fetch function. If the bank has a cache, the table is loaded from the cache
+// and fetch is called in background, unless the cache is expired (see
+// SetCacheMaxAge). If fetch is nil, it has no effect. Returns an error if fetch
+// returns error or if the bank is a snapshot.
 func (bank *Bank) UpdateExchangeRatesTable() error {
+	if bank.readOnly {
+		return ErrReadOnlyBank
+	}
 	if bank.fetchExchangeRatesTable == nil {
 		return nil
 	}
 
-	if bank.exchangeRatesTableCache == nil {
-		return bank.blockingUpdateExchangeRatesTable()
+	envelope := bank.readExchangeRatesTableCache()
+	if envelope == nil {
+		return bank.blockingUpdateExchangeRatesTable(false)
 	}
-
-	table, err := bank.exchangeRatesTableCache.Read()
-	if err != nil {
-		return bank.blockingUpdateExchangeRatesTable()
+	if bank.isCacheExpired(envelope) {
+		bank.getLogger().Info("exchange rates table cache expired", "provider", bank.getProviderName(), "fetched_at", envelope.FetchedAt)
+		err := bank.blockingUpdateExchangeRatesTable(false)
+		if err == nil || !bank.applyExchangeRatesTableCache(envelope) {
+			return err
+		}
+		bank.getLogger().Warn("using expired exchange rates table cache", "provider", bank.getProviderName(), "fetched_at", envelope.FetchedAt, "error", err)
+		return nil
+	}
+	if !bank.applyExchangeRatesTableCache(envelope) {
+		return bank.blockingUpdateExchangeRatesTable(false)
 	}
-	bank.setExchangeRatesTable(table)
 	go func() {
-		err := bank.blockingUpdateExchangeRatesTable()
-		if err != nil {
-			log.Println(err)
-		}
+		// Errors are logged and notified to the fetch error handlers
+		_ = bank.blockingUpdateExchangeRatesTable(true)
 	}()
 	return nil
 }
@@ -141,41 +204,141 @@ func NewMoneyFromAmount(amount float64, currencyIsoCode string) (*Money, error)
 func (bank *Bank) getCurrency(currencyIsoCode string) (Currency, error) {
 	currency, found := bank.Currencies[currencyIsoCode]
 	if !found {
-		return currency, fmt.Errorf("bank does not support %s currency", currencyIsoCode)
+		return currency, &UnsupportedCurrencyError{Currency: currencyIsoCode}
 	}
 	return currency, nil
 }
 
-func (bank *Bank) blockingUpdateExchangeRatesTable() error {
+// loadExchangeRatesTableCache sets the exchange rates table read from the
+// cache. Returns false if there is no cache or if it can not be read.
+func (bank *Bank) loadExchangeRatesTableCache() bool {
+	envelope := bank.readExchangeRatesTableCache()
+	return envelope != nil && bank.applyExchangeRatesTableCache(envelope)
+}
+
+// readExchangeRatesTableCache reads the cache. The envelope of a cache that
+// does not implement ExchangeRatesTableEnvelopeCache has only the table.
+// Returns nil if there is no cache or if it can not be read.
+func (bank *Bank) readExchangeRatesTableCache() *CacheEnvelope {
+	if bank.exchangeRatesTableCache == nil {
+		return nil
+	}
+	envelope, err := readCacheEnvelope(bank.exchangeRatesTableCache)
+	if err != nil {
+		bank.getLogger().Info("failed to read exchange rates table cache", "provider", bank.getProviderName(), "error", err)
+		return nil
+	}
+	return envelope
+}
+
+// applyExchangeRatesTableCache sets the exchange rates table of envelope read
+// from the cache. Returns false if the table is rejected.
+func (bank *Bank) applyExchangeRatesTableCache(envelope *CacheEnvelope) bool {
+	updatedAt := envelope.FetchedAt
+	if updatedAt.IsZero() {
+		updatedAt = time.Now()
+	}
+	err := bank.setExchangeRatesTable(envelope.Table, RateSourceCache, updatedAt)
+	if err != nil {
+		bank.getLogger().Warn("exchange rates table cache rejected", "provider", bank.getProviderName(), "error", err)
+		return false
+	}
+	bank.getLogger().Info("exchange rates table loaded from cache", "provider", bank.getProviderName(), "rates", envelope.Table.countExchangeRates())
+	return true
+}
+
+// blockingUpdateExchangeRatesTable fetches and sets the exchange rates table.
+// A failure is logged as error only if background is true, otherwise the error
+// is returned to the caller and it is logged as info, so that the default
+// logger does not report it twice.
+func (bank *Bank) blockingUpdateExchangeRatesTable(background bool) error {
+	logFailure := bank.getLogger().Info
+	if background {
+		logFailure = bank.getLogger().Error
+	}
+	start := time.Now()
 	table, err := bank.fetchExchangeRatesTable()
+	duration := time.Since(start)
 	if err != nil {
+		logFailure("failed to fetch exchange rates table", "provider", bank.getProviderName(), "duration", duration, "error", err)
+		bank.notifyFetchError(err)
 		return err
 	}
-	bank.setExchangeRatesTable(table)
+	err = bank.setExchangeRatesTable(table, RateSourceFetch, time.Now())
+	if err != nil {
+		logFailure("exchange rates table rejected", "provider", bank.getProviderName(), "duration", duration, "error", err)
+		bank.notifyFetchError(err)
+		return err
+	}
+	bank.getLogger().Info("exchange rates table fetched", "provider", bank.getProviderName(), "duration", duration, "rates", table.countExchangeRates())
+	return nil
+}
+
+func (bank *Bank) setExchangeRatesTable(table ExchangeRatesTable, source string, updatedAt time.Time) error {
+	bank.mu.Lock()
+	violations := bank.validateExchangeRatesTable(table)
+	if len(violations) > 0 {
+		bank.mu.Unlock()
+		return &ValidationError{Source: source, Violations: violations}
+	}
+	observers := bank.updateObservers
+	var oldTable, newTable ExchangeRatesTable
+	if len(observers) > 0 {
+		oldTable = bank.expandedExchangeRatesTable()
+	}
+	bank.exchangeRatesSource = source
+	bank.exchangeRatesUpdatedAt = updatedAt
+	// Set before notifying the observers, so that they see the final state
+	if source == RateSourceFetch {
+		bank.fetched = true
+	}
+	published := table
+	if bank.baseCurrencyIsoCode != "" {
+		published = ExchangeRatesTable{bank.baseCurrencyIsoCode: table.baseRates(bank.baseCurrencyIsoCode)}
+	}
+	bank.eachSupportedExchangeRate(published, func(fromCurrencyIsoCode, toCurrencyIsoCode string, rate Decimal) {
+		bank.ExchangeRatesTable[fromCurrencyIsoCode][toCurrencyIsoCode] = rate
+		delete(bank.derivedExchangeRates[fromCurrencyIsoCode], toCurrencyIsoCode)
+	})
+	if bank.inverseExchangeRates {
+		bank.deriveInverseExchangeRates(published, bank.overridePublishedRates)
+	}
+	if len(observers) > 0 {
+		newTable = bank.expandedExchangeRatesTable()
+	}
+	bank.mu.Unlock()
+	if len(observers) > 0 {
+		changes := diffExchangeRatesTables(oldTable, newTable)
+		for _, observer := range observers {
+			observer(oldTable, newTable, changes)
+		}
+	}
+	// Only fetched tables are written, to not replace the cache with seeded
+	// exchange rates
+	if bank.exchangeRatesTableCache != nil && source == RateSourceFetch {
+		err := writeCacheEnvelope(bank.exchangeRatesTableCache, &CacheEnvelope{FetchedAt: updatedAt, Provider: bank.getProviderName(), Table: table})
+		if err != nil {
+			bank.getLogger().Error("failed to write exchange rates table cache", "provider", bank.getProviderName(), "rates", table.countExchangeRates(), "error", err)
+		}
+	}
 	return nil
 }
 
-func (bank *Bank) setExchangeRatesTable(table ExchangeRatesTable) {
+// eachSupportedExchangeRate calls f for each exchange rate in table between two
+// different currencies supported by the bank.
+func (bank *Bank) eachSupportedExchangeRate(table ExchangeRatesTable, f func(fromCurrencyIsoCode, toCurrencyIsoCode string, rate Decimal)) {
 	for fromCurrencyIsoCode, fromRates := range table {
-		fromCurrency, found := bank.Currencies[fromCurrencyIsoCode]
-		if !found {
+		if _, found := bank.Currencies[fromCurrencyIsoCode]; !found {
 			continue
 		}
 		for toCurrencyIsoCode, rate := range fromRates {
 			if fromCurrencyIsoCode == toCurrencyIsoCode {
 				continue
 			}
-			toCurrency, found := bank.Currencies[toCurrencyIsoCode]
-			if !found {
+			if _, found := bank.Currencies[toCurrencyIsoCode]; !found {
 				continue
 			}
-			bank.ExchangeRatesTable[fromCurrency.IsoCode][toCurrency.IsoCode] = rate
-		}
-	}
-	if bank.exchangeRatesTableCache != nil {
-		err := bank.exchangeRatesTableCache.Write(table)
-		if err != nil {
-			log.Println(err)
+			f(fromCurrencyIsoCode, toCurrencyIsoCode, rate)
 		}
 	}
 }
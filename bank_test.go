@@ -56,10 +56,10 @@ func TestExchangeDefaultBank(t *testing.T) {
 
 func TestNewBank(t *testing.T) {
 	f := func() (money.ExchangeRatesTable, error) {
-		return money.ExchangeRatesTable{
+		return money.ExchangeRatesTableFromFloat(map[string]map[string]float64{
 			"EUR": {"EUR": 1.0, "USD": 1.2, "GBP": 1.3},
 			"AUD": {"EUR": 2.0, "USD": 2.2, "GBP": 2.3},
-		}, nil
+		}), nil
 	}
 	bank, err := money.NewBank([]money.Currency{money.EUR, money.USD}, f, nil)
 	assert.Nil(t, err)
@@ -93,10 +93,10 @@ func TestUpdateRatesError(t *testing.T) {
 
 func TestGetExchangeRate(t *testing.T) {
 	f := func() (money.ExchangeRatesTable, error) {
-		return money.ExchangeRatesTable{
+		return money.ExchangeRatesTableFromFloat(map[string]map[string]float64{
 			"EUR": {"USD": 1.2, "GBP": 1.3},
 			"AUD": {"USD": 2.2, "GBP": 2.3},
-		}, nil
+		}), nil
 	}
 	bank, err := money.NewBank([]money.Currency{money.EUR, money.USD}, f, nil)
 	assert.Nil(t, err)
@@ -126,10 +126,10 @@ func TestRatesCache(t *testing.T) {
 	f := func() (money.ExchangeRatesTable, error) {
 		// Simutate fetch from Internet
 		time.Sleep(10 * time.Millisecond)
-		return money.ExchangeRatesTable{
+		return money.ExchangeRatesTableFromFloat(map[string]map[string]float64{
 			"EUR": {"USD": rand.Float64()},
 			"USD": {"EUR": rand.Float64()},
-		}, nil
+		}), nil
 	}
 	bank, _ := money.NewBank(c, f, fileCache)
 
@@ -140,7 +140,7 @@ func TestRatesCache(t *testing.T) {
 
 	cachedRates, err := fileCache.Read()
 	assert.Nil(t, err)
-	assert.Equal(t, rate1, cachedRates["EUR"]["USD"])
+	assert.Equal(t, rate1, cachedRates["EUR"]["USD"].Float64())
 
 	bank, _ = money.NewBank(c, f, fileCache)
 	rate2, _ := bank.GetExchangeRate("EUR", "USD")
@@ -152,13 +152,13 @@ func TestRatesCache(t *testing.T) {
 	assert.NotEqual(t, rate1, rate3)
 	cachedRates, err = fileCache.Read()
 	assert.Nil(t, err)
-	assert.Equal(t, rate3, cachedRates["EUR"]["USD"])
+	assert.Equal(t, rate3, cachedRates["EUR"]["USD"].Float64())
 }
 
 func TestIncBank(t *testing.T) {
-	counter := 0.0
+	var counter int64
 	var bank, _ = money.NewBank(money.AllCurrencies, func() (money.ExchangeRatesTable, error) {
-		counter += 1.0
+		counter++
 		table := make(money.ExchangeRatesTable)
 		for _, fromCurrency := range money.AllCurrencies {
 			table[fromCurrency.IsoCode] = make(money.ExchangeRates)
@@ -166,7 +166,7 @@ func TestIncBank(t *testing.T) {
 				if fromCurrency.IsoCode == toCurrency.IsoCode {
 					continue
 				}
-				table[fromCurrency.IsoCode][toCurrency.IsoCode] = (float64(fromCurrency.SubunitToUnit) / float64(toCurrency.SubunitToUnit)) * counter
+				table[fromCurrency.IsoCode][toCurrency.IsoCode] = money.NewDecimal(int64(fromCurrency.SubunitToUnit)*counter, int64(toCurrency.SubunitToUnit))
 			}
 		}
 		return table, nil
@@ -0,0 +1,37 @@
+package banks
+
+import (
+	"os"
+
+	"github.com/pioz/money"
+)
+
+// NewCSVBank creates a bank with the exchange rates table read from the CSV
+// file at path, in the given format (see money.ReadExchangeRatesTableCSV). The
+// file is read again at each update of the exchange rates table. nil
+// currencies means money.AllCurrencies.
+func NewCSVBank(currencies []money.Currency, path string, format money.CSVFormat) (*money.Bank, error) {
+	if currencies == nil {
+		currencies = money.AllCurrencies
+	}
+	provider := NewCSVProvider(currencies, path, format)
+	return money.NewBankWithOptions(
+		money.WithCurrencies(currencies),
+		money.WithFetcher(provider.Fetch),
+		money.WithProviderName(provider.Name),
+	)
+}
+
+// NewCSVProvider creates a provider that reads the exchange rates table of
+// currencies from the CSV file at path, in the given format. nil currencies
+// means money.AllCurrencies.
+func NewCSVProvider(currencies []money.Currency, path string, format money.CSVFormat) Provider {
+	return Provider{Name: "csv", Fetch: func() (money.ExchangeRatesTable, error) {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		return money.ReadExchangeRatesTableCSV(f, format, currencies)
+	}}
+}
@@ -0,0 +1,53 @@
+package banks_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pioz/money"
+	"github.com/pioz/money/banks"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCSVBank(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rates.csv")
+	err := os.WriteFile(path, []byte(",EUR,USD\nEUR,,1.16\nUSD,0.87,\n"), 0644)
+	assert.Nil(t, err)
+
+	bank, err := banks.NewCSVBank([]money.Currency{money.EUR, money.USD}, path, money.CSVMatrix)
+	assert.Nil(t, err)
+	rate, err := bank.GetExchangeRate("EUR", "USD")
+	assert.Nil(t, err)
+	assert.Equal(t, 1.16, rate)
+
+	// The file is read again at each update
+	err = os.WriteFile(path, []byte(",EUR,USD\nEUR,,1.2\nUSD,0.8,\n"), 0644)
+	assert.Nil(t, err)
+	err = bank.UpdateExchangeRatesTable()
+	assert.Nil(t, err)
+	rate, err = bank.GetExchangeRate("EUR", "USD")
+	assert.Nil(t, err)
+	assert.Equal(t, 1.2, rate)
+}
+
+func TestCSVBankUnsupportedCurrency(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rates.csv")
+	err := os.WriteFile(path, []byte("from,to,rate\nEUR,USD,1.16\nEUR,JPY,130\n"), 0644)
+	assert.Nil(t, err)
+
+	_, err = banks.NewCSVBank([]money.Currency{money.EUR, money.USD}, path, money.CSVLong)
+	assert.True(t, errors.Is(err, money.ErrUnsupportedCurrency))
+	assert.Equal(t, "line 3, column 2: bank does not support JPY currency", err.Error())
+
+	// nil currencies means all currencies
+	bank, err := banks.NewCSVBank(nil, path, money.CSVLong)
+	assert.Nil(t, err)
+	rate, err := bank.GetExchangeRate("EUR", "JPY")
+	assert.Nil(t, err)
+	assert.Equal(t, 130.0, rate)
+
+	_, err = banks.NewCSVBank([]money.Currency{money.EUR, money.USD}, filepath.Join(t.TempDir(), "not-found.csv"), money.CSVLong)
+	assert.True(t, errors.Is(err, os.ErrNotExist))
+}
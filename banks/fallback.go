@@ -0,0 +1,176 @@
+package banks
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/pioz/money"
+)
+
+// A Provider is a named source of exchange rates tables.
+type Provider struct {
+	Name  string
+	Fetch money.FetchExchangeRatesTableFunc
+}
+
+// NewStaticProvider creates a provider that always returns table, for example
+// a seed table loaded from a file.
+func NewStaticProvider(name string, table money.ExchangeRatesTable) Provider {
+	return Provider{Name: name, Fetch: func() (money.ExchangeRatesTable, error) {
+		return table, nil
+	}}
+}
+
+// FallbackMode defines how a FallbackChain combines the tables of its
+// providers.
+type FallbackMode int
+
+const (
+	// FirstSuccess uses the table of the first provider that does not fail.
+	FirstSuccess FallbackMode = iota
+	// MergeByPair fetches the tables of all providers and merges them pair by
+	// pair: each exchange rate comes from the first provider that has it.
+	MergeByPair
+)
+
+// ErrNoExchangeRates is the error of a provider that returns an empty
+// exchange rates table.
+var ErrNoExchangeRates = errors.New("provider returned no exchange rates")
+
+// ProviderError is the error of a provider of a FallbackChain.
+type ProviderError struct {
+	Provider string
+	Err      error
+}
+
+func (e *ProviderError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Provider, e.Err)
+}
+
+// Unwrap returns the error of the provider.
+func (e *ProviderError) Unwrap() error {
+	return e.Err
+}
+
+// FallbackChainError is returned by FallbackChain.Fetch when all providers
+// fail. Errors has an error for each provider, in order.
+type FallbackChainError struct {
+	Errors []*ProviderError
+}
+
+func (e *FallbackChainError) Error() string {
+	messages := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		messages[i] = err.Error()
+	}
+	return "all providers failed: " + strings.Join(messages, "; ")
+}
+
+// A FallbackChain combines several providers in order of precedence, for
+// example freecurrencyapi, then a secondary source and then a static seed
+// table. Its Fetch method is a money.FetchExchangeRatesTableFunc, so a chain
+// can be used to create a bank or as provider of another chain. The chain
+// records which provider supplied each exchange rate of the last fetched
+// table (see RateProvider).
+type FallbackChain struct {
+	providers []Provider
+	mode      FallbackMode
+	// OnError, if not nil, is called for each provider that fails, including
+	// the ones skipped because a following provider succeeds.
+	OnError func(err *ProviderError)
+	// Map from currency ISO code to currency ISO code of the provider names
+	sources map[string]map[string]string
+	mu      sync.RWMutex
+}
+
+// NewFallbackChain creates a chain of providers that combines their tables
+// with mode.
+func NewFallbackChain(mode FallbackMode, providers ...Provider) *FallbackChain {
+	return &FallbackChain{
+		providers: providers,
+		mode:      mode,
+		sources:   make(map[string]map[string]string),
+	}
+}
+
+// Fetch fetches the exchange rates table from the providers of the chain.
+// Returns a FallbackChainError if all providers fail. A provider that returns
+// an empty table fails with ErrNoExchangeRates.
+func (chain *FallbackChain) Fetch() (money.ExchangeRatesTable, error) {
+	table := make(money.ExchangeRatesTable)
+	sources := make(map[string]map[string]string)
+	chainErr := &FallbackChainError{}
+	for _, provider := range chain.providers {
+		providerTable, err := provider.Fetch()
+		if err == nil && isEmptyExchangeRatesTable(providerTable) {
+			err = ErrNoExchangeRates
+		}
+		if err != nil {
+			providerErr := &ProviderError{Provider: provider.Name, Err: err}
+			chainErr.Errors = append(chainErr.Errors, providerErr)
+			if chain.OnError != nil {
+				chain.OnError(providerErr)
+			}
+			continue
+		}
+		mergeExchangeRatesTable(table, sources, providerTable, provider.Name)
+		if chain.mode == FirstSuccess {
+			break
+		}
+	}
+	if len(chainErr.Errors) == len(chain.providers) {
+		return nil, chainErr
+	}
+
+	chain.mu.Lock()
+	defer chain.mu.Unlock()
+	chain.sources = sources
+	return table, nil
+}
+
+// RateProvider returns the name of the provider that supplied the exchange
+// rate from the currency with ISO code fromCurrencyIsoCode to the currency
+// with ISO code toCurrencyIsoCode in the last fetched table. Returns false if
+// the last fetched table does not have the exchange rate.
+func (chain *FallbackChain) RateProvider(fromCurrencyIsoCode, toCurrencyIsoCode string) (string, bool) {
+	chain.mu.RLock()
+	defer chain.mu.RUnlock()
+	name, found := chain.sources[fromCurrencyIsoCode][toCurrencyIsoCode]
+	return name, found
+}
+
+// Private functions
+
+// mergeExchangeRatesTable adds to table the exchange rates of providerTable
+// that table does not have yet, recording in sources that they have been
+// supplied by the provider with name providerName. Zero exchange rates are
+// ignored.
+func mergeExchangeRatesTable(table money.ExchangeRatesTable, sources map[string]map[string]string, providerTable money.ExchangeRatesTable, providerName string) {
+	for fromCurrencyIsoCode, rates := range providerTable {
+		for toCurrencyIsoCode, rate := range rates {
+			if rate.Sign() <= 0 {
+				continue
+			}
+			if _, found := table[fromCurrencyIsoCode][toCurrencyIsoCode]; found {
+				continue
+			}
+			if table[fromCurrencyIsoCode] == nil {
+				table[fromCurrencyIsoCode] = make(money.ExchangeRates)
+				sources[fromCurrencyIsoCode] = make(map[string]string)
+			}
+			table[fromCurrencyIsoCode][toCurrencyIsoCode] = rate
+			sources[fromCurrencyIsoCode][toCurrencyIsoCode] = providerName
+		}
+	}
+}
+
+func isEmptyExchangeRatesTable(table money.ExchangeRatesTable) bool {
+	for _, rates := range table {
+		if len(rates) > 0 {
+			return false
+		}
+	}
+	return true
+}
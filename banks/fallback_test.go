@@ -0,0 +1,99 @@
+package banks_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/pioz/money"
+	"github.com/pioz/money/banks"
+	"github.com/stretchr/testify/assert"
+)
+
+func failingProvider(name string) banks.Provider {
+	return banks.Provider{Name: name, Fetch: func() (money.ExchangeRatesTable, error) {
+		return nil, errors.New("provider is down")
+	}}
+}
+
+func TestFallbackChainFirstSuccess(t *testing.T) {
+	var failed []string
+	chain := banks.NewFallbackChain(banks.FirstSuccess,
+		failingProvider("primary"),
+		banks.NewStaticProvider("secondary", money.ExchangeRatesTableFromFloat(map[string]map[string]float64{"EUR": {"USD": 1.2}})),
+		banks.NewStaticProvider("seed", money.ExchangeRatesTableFromFloat(map[string]map[string]float64{"EUR": {"USD": 1.1, "JPY": 130}})),
+	)
+	chain.OnError = func(err *banks.ProviderError) {
+		failed = append(failed, err.Provider)
+	}
+	table, err := chain.Fetch()
+	assert.Nil(t, err)
+	assert.Equal(t, money.ExchangeRatesTableFromFloat(map[string]map[string]float64{"EUR": {"USD": 1.2}}), table)
+	assert.Equal(t, []string{"primary"}, failed)
+
+	name, found := chain.RateProvider("EUR", "USD")
+	assert.True(t, found)
+	assert.Equal(t, "secondary", name)
+	_, found = chain.RateProvider("EUR", "JPY")
+	assert.False(t, found)
+}
+
+func TestFallbackChainMergeByPair(t *testing.T) {
+	chain := banks.NewFallbackChain(banks.MergeByPair,
+		banks.NewStaticProvider("primary", money.ExchangeRatesTableFromFloat(map[string]map[string]float64{"EUR": {"USD": 1.2}})),
+		failingProvider("secondary"),
+		banks.NewStaticProvider("seed", money.ExchangeRatesTableFromFloat(map[string]map[string]float64{"EUR": {"USD": 1.1, "JPY": 130}, "USD": {"EUR": 0.8, "JPY": 0}})),
+	)
+	table, err := chain.Fetch()
+	assert.Nil(t, err)
+	assert.Equal(t, money.ExchangeRatesTableFromFloat(map[string]map[string]float64{
+		"EUR": {"USD": 1.2, "JPY": 130},
+		"USD": {"EUR": 0.8},
+	}), table)
+
+	name, _ := chain.RateProvider("EUR", "USD")
+	assert.Equal(t, "primary", name)
+	name, _ = chain.RateProvider("EUR", "JPY")
+	assert.Equal(t, "seed", name)
+	name, _ = chain.RateProvider("USD", "EUR")
+	assert.Equal(t, "seed", name)
+	_, found := chain.RateProvider("USD", "JPY")
+	assert.False(t, found)
+}
+
+func TestFallbackChainAllProvidersFail(t *testing.T) {
+	chain := banks.NewFallbackChain(banks.FirstSuccess,
+		failingProvider("primary"),
+		banks.NewStaticProvider("empty", money.ExchangeRatesTable{}),
+	)
+	table, err := chain.Fetch()
+	assert.Nil(t, table)
+	assert.EqualError(t, err, "all providers failed: primary: provider is down; empty: provider returned no exchange rates")
+	assert.True(t, errors.Is(err.(*banks.FallbackChainError).Errors[1], banks.ErrNoExchangeRates))
+}
+
+func TestFallbackChainNested(t *testing.T) {
+	inner := banks.NewFallbackChain(banks.FirstSuccess,
+		failingProvider("primary"),
+		banks.NewStaticProvider("secondary", money.ExchangeRatesTableFromFloat(map[string]map[string]float64{"EUR": {"USD": 1.2}})),
+	)
+	chain := banks.NewFallbackChain(banks.MergeByPair,
+		banks.Provider{Name: "live", Fetch: inner.Fetch},
+		banks.NewStaticProvider("seed", money.ExchangeRatesTableFromFloat(map[string]map[string]float64{"USD": {"EUR": 0.8}})),
+	)
+	bank, err := money.NewBankWithOptions(
+		money.WithCurrencies([]money.Currency{money.EUR, money.USD}),
+		money.WithFetcher(chain.Fetch),
+	)
+	assert.Nil(t, err)
+	rate, err := bank.GetExchangeRate("EUR", "USD")
+	assert.Nil(t, err)
+	assert.Equal(t, 1.2, rate)
+	rate, err = bank.GetExchangeRate("USD", "EUR")
+	assert.Nil(t, err)
+	assert.Equal(t, 0.8, rate)
+
+	name, _ := chain.RateProvider("EUR", "USD")
+	assert.Equal(t, "live", name)
+	name, _ = inner.RateProvider("EUR", "USD")
+	assert.Equal(t, "secondary", name)
+}
@@ -17,7 +17,20 @@ type response struct {
 }
 
 func NewFreecurrencyBank(currencies []money.Currency, apiKey string, cache money.ExchangeRatesTableCache) (*money.Bank, error) {
-	return money.NewBank(currencies, func() (money.ExchangeRatesTable, error) {
+	provider := NewFreecurrencyProvider(currencies, apiKey)
+	return money.NewBankWithOptions(
+		money.WithCurrencies(currencies),
+		money.WithFetcher(provider.Fetch),
+		money.WithCache(cache),
+		money.WithProviderName(provider.Name),
+	)
+}
+
+// NewFreecurrencyProvider creates a provider that fetches from
+// https://freecurrencyapi.net/ the exchange rates table of currencies, for
+// example to use it in a FallbackChain.
+func NewFreecurrencyProvider(currencies []money.Currency, apiKey string) Provider {
+	return Provider{Name: "freecurrencyapi", Fetch: func() (money.ExchangeRatesTable, error) {
 		table := make(money.ExchangeRatesTable)
 		for _, currency := range currencies {
 			toRates, err := getExchangeRatesTable(apiKey, currency.IsoCode)
@@ -27,7 +40,22 @@ func NewFreecurrencyBank(currencies []money.Currency, apiKey string, cache money
 			table[currency.IsoCode] = toRates
 		}
 		return table, nil
-	}, cache)
+	}}
+}
+
+// NewFreecurrencyBaseProvider creates a provider that fetches from
+// https://freecurrencyapi.net/ only the exchange rates from the currency with
+// ISO code base, with a single request. It is meant for a bank that stores the
+// exchange rates table in compact form (see money.WithCompactExchangeRates),
+// that computes the cross rates from the row of the base currency.
+func NewFreecurrencyBaseProvider(base, apiKey string) Provider {
+	return Provider{Name: "freecurrencyapi", Fetch: func() (money.ExchangeRatesTable, error) {
+		rates, err := getExchangeRatesTable(apiKey, base)
+		if err != nil {
+			return nil, err
+		}
+		return money.ExchangeRatesTable{base: rates}, nil
+	}}
 }
 
 func getExchangeRatesTable(apiKey, baseCurrency string) (money.ExchangeRates, error) {
@@ -3,17 +3,20 @@ package banks
 import "github.com/pioz/money"
 
 func NewOneBank(currencies []money.Currency) (*money.Bank, error) {
-	return money.NewBank(money.AllCurrencies, func() (money.ExchangeRatesTable, error) {
-		table := make(money.ExchangeRatesTable)
-		for _, fromCurrency := range money.AllCurrencies {
-			table[fromCurrency.IsoCode] = make(money.ExchangeRates)
+	base := money.USD
+	return money.NewBankWithOptions(
+		money.WithCurrencies(money.AllCurrencies),
+		// The cross rates are computed by the bank from the row of the base
+		// currency
+		money.WithCompactExchangeRates(base.IsoCode),
+		money.WithFetcher(func() (money.ExchangeRatesTable, error) {
+			rates := make(money.ExchangeRates)
 			for _, toCurrency := range money.AllCurrencies {
-				if fromCurrency.IsoCode == toCurrency.IsoCode {
-					continue
+				if toCurrency.IsoCode != base.IsoCode {
+					rates[toCurrency.IsoCode] = money.NewDecimal(int64(base.SubunitToUnit), int64(toCurrency.SubunitToUnit))
 				}
-				table[fromCurrency.IsoCode][toCurrency.IsoCode] = float64(fromCurrency.SubunitToUnit) / float64(toCurrency.SubunitToUnit)
 			}
-		}
-		return table, nil
-	}, nil)
+			return money.ExchangeRatesTable{base.IsoCode: rates}, nil
+		}),
+	)
 }
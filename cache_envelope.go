@@ -0,0 +1,155 @@
+package money
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+)
+
+// CacheFormatVersion is the version of the CacheEnvelope format written by
+// this package.
+const CacheFormatVersion = 1
+
+// CacheEnvelope is the self-describing content of a cache: the exchange rates
+// table together with its metadata.
+type CacheEnvelope struct {
+	// Version of the format, 0 for the tables cached without envelope by
+	// previous versions of the package.
+	Version int `json:"version"`
+	// Time of the fetch of the table, zero if unknown.
+	FetchedAt time.Time `json:"fetched_at"`
+	// Name of the provider of the table (see Bank.SetProviderName).
+	Provider string             `json:"provider"`
+	Table    ExchangeRatesTable `json:"table"`
+}
+
+// ExchangeRatesTableEnvelopeCache is the interface implemented by the caches
+// that store the metadata of the exchange rates table. When the cache of a
+// bank implements it, the bank writes the fetch time and the provider name of
+// the table, and when the table is loaded from the cache the fetch time is
+// used as time of the last update of the exchange rates (see
+// Bank.SetMaxStaleness).
+type ExchangeRatesTableEnvelopeCache interface {
+	ExchangeRatesTableCache
+	ReadEnvelope() (*CacheEnvelope, error)
+	WriteEnvelope(envelope *CacheEnvelope) error
+}
+
+// CacheEncoding is the interface that can be implemented to encode a
+// CacheEnvelope in a cache, for example in ExchangeRatesTableFileCache.
+type CacheEncoding interface {
+	Encode(w io.Writer, envelope *CacheEnvelope) error
+	Decode(r io.Reader) (*CacheEnvelope, error)
+}
+
+// GobCacheEncoding encodes the envelope with gob. It also decodes the tables
+// cached without envelope by previous versions of the package.
+type GobCacheEncoding struct{}
+
+// Encode implements the Encode method of CacheEncoding interface.
+func (GobCacheEncoding) Encode(w io.Writer, envelope *CacheEnvelope) error {
+	return gob.NewEncoder(w).Encode(envelope)
+}
+
+// Decode implements the Decode method of CacheEncoding interface.
+func (GobCacheEncoding) Decode(r io.Reader) (*CacheEnvelope, error) {
+	// The read bytes are kept to decode again a table without envelope
+	var read bytes.Buffer
+	var envelope CacheEnvelope
+	err := gob.NewDecoder(io.TeeReader(r, &read)).Decode(&envelope)
+	if err != nil {
+		envelope = CacheEnvelope{}
+		if _, legacyErr := envelope.Table.ReadFrom(io.MultiReader(&read, r)); legacyErr != nil {
+			return nil, err
+		}
+		return &envelope, nil
+	}
+	return &envelope, checkCacheFormatVersion(envelope.Version)
+}
+
+// JSONCacheEncoding encodes the envelope with JSON, so that the cache can be
+// read by other tools.
+type JSONCacheEncoding struct{}
+
+// Encode implements the Encode method of CacheEncoding interface.
+func (JSONCacheEncoding) Encode(w io.Writer, envelope *CacheEnvelope) error {
+	return json.NewEncoder(w).Encode(envelope)
+}
+
+// Decode implements the Decode method of CacheEncoding interface.
+func (JSONCacheEncoding) Decode(r io.Reader) (*CacheEnvelope, error) {
+	var envelope CacheEnvelope
+	err := json.NewDecoder(r).Decode(&envelope)
+	if err != nil {
+		return nil, err
+	}
+	return &envelope, checkCacheFormatVersion(envelope.Version)
+}
+
+// GzipCacheEncoding compresses with gzip the envelope encoded by Encoding. A
+// nil Encoding means GobCacheEncoding.
+type GzipCacheEncoding struct {
+	Encoding CacheEncoding
+}
+
+// Encode implements the Encode method of CacheEncoding interface.
+func (e GzipCacheEncoding) Encode(w io.Writer, envelope *CacheEnvelope) error {
+	zw := gzip.NewWriter(w)
+	err := e.encoding().Encode(zw, envelope)
+	if err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// Decode implements the Decode method of CacheEncoding interface.
+func (e GzipCacheEncoding) Decode(r io.Reader) (*CacheEnvelope, error) {
+	zr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	return e.encoding().Decode(zr)
+}
+
+// Private functions
+
+func (e GzipCacheEncoding) encoding() CacheEncoding {
+	if e.Encoding == nil {
+		return GobCacheEncoding{}
+	}
+	return e.Encoding
+}
+
+// readCacheEnvelope reads the envelope of cache. The envelope of a cache that
+// does not implement ExchangeRatesTableEnvelopeCache has only the table.
+func readCacheEnvelope(cache ExchangeRatesTableCache) (*CacheEnvelope, error) {
+	if envelopeCache, ok := cache.(ExchangeRatesTableEnvelopeCache); ok {
+		return envelopeCache.ReadEnvelope()
+	}
+	table, err := cache.Read()
+	if err != nil {
+		return nil, err
+	}
+	return &CacheEnvelope{Table: table}, nil
+}
+
+// writeCacheEnvelope writes the envelope to cache. A cache that does not
+// implement ExchangeRatesTableEnvelopeCache gets only the table.
+func writeCacheEnvelope(cache ExchangeRatesTableCache, envelope *CacheEnvelope) error {
+	if envelopeCache, ok := cache.(ExchangeRatesTableEnvelopeCache); ok {
+		return envelopeCache.WriteEnvelope(envelope)
+	}
+	return cache.Write(envelope.Table)
+}
+
+func checkCacheFormatVersion(version int) error {
+	if version > CacheFormatVersion {
+		return fmt.Errorf("unsupported cache format version %d", version)
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+package money_test
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pioz/money"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFileCacheEncodings(t *testing.T) {
+	fetchedAt := time.Date(2026, 3, 2, 10, 30, 0, 0, time.UTC)
+	table := money.ExchangeRatesTableFromFloat(map[string]map[string]float64{"EUR": {"USD": 1.2}})
+	encodings := []money.CacheEncoding{
+		nil,
+		money.GobCacheEncoding{},
+		money.JSONCacheEncoding{},
+		money.GzipCacheEncoding{},
+		money.GzipCacheEncoding{Encoding: money.JSONCacheEncoding{}},
+	}
+	for _, encoding := range encodings {
+		fileCache := money.ExchangeRatesTableFileCache{FilePath: filepath.Join(t.TempDir(), "cache"), Encoding: encoding}
+		err := fileCache.WriteEnvelope(&money.CacheEnvelope{FetchedAt: fetchedAt, Provider: "test", Table: table})
+		assert.Nil(t, err)
+
+		envelope, err := fileCache.ReadEnvelope()
+		assert.Nil(t, err)
+		assert.Equal(t, money.CacheFormatVersion, envelope.Version)
+		assert.True(t, fetchedAt.Equal(envelope.FetchedAt))
+		assert.Equal(t, "test", envelope.Provider)
+		assert.Equal(t, table, envelope.Table)
+	}
+}
+
+func TestFileCacheJSONEncoding(t *testing.T) {
+	fileCache := money.ExchangeRatesTableFileCache{FilePath: filepath.Join(t.TempDir(), "cache.json"), Encoding: money.JSONCacheEncoding{}}
+	err := fileCache.WriteEnvelope(&money.CacheEnvelope{
+		FetchedAt: time.Date(2026, 3, 2, 10, 30, 0, 0, time.UTC),
+		Provider:  "test",
+		Table:     money.ExchangeRatesTableFromFloat(map[string]map[string]float64{"EUR": {"USD": 1.2}}),
+	})
+	assert.Nil(t, err)
+
+	// The file can be read without the package
+	content, err := os.ReadFile(fileCache.FilePath)
+	assert.Nil(t, err)
+	var decoded map[string]interface{}
+	err = json.Unmarshal(content, &decoded)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"version":    1.0,
+		"fetched_at": "2026-03-02T10:30:00Z",
+		"provider":   "test",
+		"table":      map[string]interface{}{"EUR": map[string]interface{}{"USD": 1.2}},
+	}, decoded)
+
+	err = os.WriteFile(fileCache.FilePath, []byte(`{"version": 2, "table": {}}`), 0644)
+	assert.Nil(t, err)
+	_, err = fileCache.Read()
+	assert.EqualError(t, err, "unsupported cache format version 2")
+
+	// A gob file is not readable with the JSON encoding
+	err = money.ExchangeRatesTableFileCache{FilePath: fileCache.FilePath}.Write(money.ExchangeRatesTable{})
+	assert.Nil(t, err)
+	_, err = fileCache.Read()
+	assert.NotNil(t, err)
+}
+
+func TestFileCacheReadTableWithoutEnvelope(t *testing.T) {
+	fileCache := money.ExchangeRatesTableFileCache{FilePath: filepath.Join(t.TempDir(), "cache")}
+	table := money.ExchangeRatesTableFromFloat(map[string]map[string]float64{"EUR": {"USD": 1.2}})
+	f, err := os.Create(fileCache.FilePath)
+	assert.Nil(t, err)
+	_, err = table.WriteTo(f)
+	assert.Nil(t, err)
+	f.Close()
+
+	envelope, err := fileCache.ReadEnvelope()
+	assert.Nil(t, err)
+	assert.Equal(t, 0, envelope.Version)
+	assert.True(t, envelope.FetchedAt.IsZero())
+	assert.Equal(t, table, envelope.Table)
+}
+
+func TestBankWithEnvelopeCache(t *testing.T) {
+	fileCache := money.ExchangeRatesTableFileCache{FilePath: filepath.Join(t.TempDir(), "cache")}
+	bank, err := money.NewBankWithOptions(
+		money.WithCurrencies([]money.Currency{money.EUR, money.USD}),
+		money.WithFetcher(func() (money.ExchangeRatesTable, error) {
+			return money.ExchangeRatesTableFromFloat(map[string]map[string]float64{"EUR": {"USD": 1.2}}), nil
+		}),
+		money.WithCache(fileCache),
+		money.WithProviderName("test"),
+	)
+	assert.Nil(t, err)
+	envelope, err := fileCache.ReadEnvelope()
+	assert.Nil(t, err)
+	assert.Equal(t, "test", envelope.Provider)
+	eur, _ := bank.NewMoney(100, "EUR")
+	receipt, _ := eur.ExchangeWithReceipt("USD")
+	assert.True(t, receipt.RateTime.Equal(envelope.FetchedAt))
+
+	// The fetch time of the cached table is the time of the exchange rates
+	fetchedAt := time.Now().Add(-2 * time.Hour)
+	err = fileCache.WriteEnvelope(&money.CacheEnvelope{FetchedAt: fetchedAt, Table: envelope.Table})
+	assert.Nil(t, err)
+	bank, err = money.NewBankWithOptions(
+		money.WithCurrencies([]money.Currency{money.EUR, money.USD}),
+		money.WithFetcher(func() (money.ExchangeRatesTable, error) {
+			return nil, os.ErrDeadlineExceeded
+		}),
+		money.WithCache(fileCache),
+		money.WithLogger(nil),
+		money.WithMaxStaleness(time.Hour),
+		money.WithNonBlockingStartup(),
+	)
+	assert.Nil(t, err)
+	defer bank.Close()
+	eur, _ = bank.NewMoney(100, "EUR")
+	receipt, err = eur.ExchangeWithReceipt("USD")
+	assert.True(t, errors.Is(err, money.ErrStaleExchangeRates))
+	assert.Nil(t, receipt)
+}
@@ -0,0 +1,29 @@
+package money
+
+import "time"
+
+// SetCacheMaxAge sets the maximum age of the cached exchange rates table. The
+// age is measured from the fetch time stored in the cache, so it requires a
+// cache that implements ExchangeRatesTableEnvelopeCache: the tables of the
+// other caches, and the tables cached without fetch time, never expire. When
+// the cached table is older than maxAge, UpdateExchangeRatesTable fetches the
+// table before returning, and it uses the expired cached table only if the
+// fetch fails. A bank started with WithNonBlockingStartup loads also an
+// expired cached table, while it fetches in background. Zero, the default,
+// disables the expiration. It has no effect if the bank is a snapshot.
+func (bank *Bank) SetCacheMaxAge(maxAge time.Duration) {
+	if bank.readOnly {
+		return
+	}
+	bank.mu.Lock()
+	defer bank.mu.Unlock()
+	bank.cacheMaxAge = maxAge
+}
+
+// Private functions
+
+func (bank *Bank) isCacheExpired(envelope *CacheEnvelope) bool {
+	bank.mu.RLock()
+	defer bank.mu.RUnlock()
+	return bank.cacheMaxAge > 0 && !envelope.FetchedAt.IsZero() && time.Since(envelope.FetchedAt) > bank.cacheMaxAge
+}
@@ -0,0 +1,108 @@
+package money_test
+
+import (
+	"errors"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pioz/money"
+	"github.com/stretchr/testify/assert"
+)
+
+func writeCacheFetchedAt(t *testing.T, fetchedAt time.Time) money.ExchangeRatesTableFileCache {
+	fileCache := money.ExchangeRatesTableFileCache{FilePath: filepath.Join(t.TempDir(), "cache")}
+	err := fileCache.WriteEnvelope(&money.CacheEnvelope{
+		FetchedAt: fetchedAt,
+		Table:     money.ExchangeRatesTableFromFloat(map[string]map[string]float64{"EUR": {"USD": 1.1}}),
+	})
+	assert.Nil(t, err)
+	return fileCache
+}
+
+func TestSetCacheMaxAgeFreshCache(t *testing.T) {
+	fileCache := writeCacheFetchedAt(t, time.Now().Add(-time.Minute))
+	release := make(chan struct{})
+	bank, err := money.NewBankWithOptions(
+		money.WithCurrencies([]money.Currency{money.EUR, money.USD}),
+		money.WithFetcher(func() (money.ExchangeRatesTable, error) {
+			<-release
+			return money.ExchangeRatesTableFromFloat(map[string]map[string]float64{"EUR": {"USD": 1.2}}), nil
+		}),
+		money.WithCache(fileCache),
+		money.WithCacheMaxAge(time.Hour),
+	)
+	// The fetch is done in background
+	assert.Nil(t, err)
+	rate, err := bank.GetExchangeRate("EUR", "USD")
+	assert.Nil(t, err)
+	assert.Equal(t, 1.1, rate)
+
+	// Wait for the background fetch to write the cache before the temporary
+	// directory is removed
+	close(release)
+	assert.Eventually(t, func() bool {
+		table, err := fileCache.Read()
+		return err == nil && table["EUR"]["USD"].String() == "1.2"
+	}, time.Second, time.Millisecond)
+}
+
+func TestSetCacheMaxAgeExpiredCache(t *testing.T) {
+	fileCache := writeCacheFetchedAt(t, time.Now().Add(-2*time.Hour))
+	var fetches int32
+	bank, err := money.NewBankWithOptions(
+		money.WithCurrencies([]money.Currency{money.EUR, money.USD}),
+		money.WithFetcher(func() (money.ExchangeRatesTable, error) {
+			atomic.AddInt32(&fetches, 1)
+			return money.ExchangeRatesTableFromFloat(map[string]map[string]float64{"EUR": {"USD": 1.2}}), nil
+		}),
+		money.WithCache(fileCache),
+		money.WithCacheMaxAge(time.Hour),
+	)
+	// The fetch is done before returning
+	assert.Nil(t, err)
+	rate, err := bank.GetExchangeRate("EUR", "USD")
+	assert.Nil(t, err)
+	assert.Equal(t, 1.2, rate)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&fetches))
+
+	envelope, err := fileCache.ReadEnvelope()
+	assert.Nil(t, err)
+	assert.Less(t, time.Since(envelope.FetchedAt), time.Minute)
+}
+
+func TestSetCacheMaxAgeExpiredCacheFetchError(t *testing.T) {
+	fileCache := writeCacheFetchedAt(t, time.Now().Add(-2*time.Hour))
+	logger := &recordLogger{}
+	bank, err := money.NewBankWithOptions(
+		money.WithCurrencies([]money.Currency{money.EUR, money.USD}),
+		money.WithFetcher(func() (money.ExchangeRatesTable, error) {
+			return nil, errors.New("provider is down")
+		}),
+		money.WithCache(fileCache),
+		money.WithCacheMaxAge(time.Hour),
+		money.WithLogger(logger),
+	)
+	// Fallback to the expired cache
+	assert.Nil(t, err)
+	rate, err := bank.GetExchangeRate("EUR", "USD")
+	assert.Nil(t, err)
+	assert.Equal(t, 1.1, rate)
+	msgs := make([]string, len(logger.events))
+	for i, event := range logger.events {
+		msgs[i] = event.msg
+	}
+	assert.Equal(t, []string{
+		"exchange rates table cache expired",
+		"failed to fetch exchange rates table",
+		"exchange rates table loaded from cache",
+		"using expired exchange rates table cache",
+	}, msgs)
+
+	// If the expired cache is rejected, the fetch error is returned
+	err = bank.SetValidationRules(&money.ValidationRules{RequiredCurrencies: []string{"EUR", "USD"}})
+	assert.Nil(t, err)
+	err = bank.UpdateExchangeRatesTable()
+	assert.EqualError(t, err, "provider is down")
+}
@@ -0,0 +1,102 @@
+package money
+
+// SetCompactExchangeRates makes the bank store the exchange rates table in
+// compact form, as the single row of the currency with ISO code baseIsoCode:
+// the exchange rates from the base currency to each other currency. The cross
+// rate from a currency X to a currency Y is computed on lookup as
+// base→Y / base→X, so the bank stores N exchange rates instead of N×N, and the
+// fetch function needs to return only the row of the base currency.
+//
+// Each exchange rates table set in the bank is reduced to the row of the base
+// currency of ExchangeRatesTable.Rebase, and the current table is reduced
+// immediately. The validation rules (see SetValidationRules) apply also to the
+// cross rates derived from the base currency, and the observers (see
+// OnExchangeRatesTableUpdate) receive the full tables derived from the base
+// currency. An empty baseIsoCode restores the full table, derived from the
+// row of the base currency with ExchangeRatesTableFromBase. Inverse exchange
+// rates (see SetInverseExchangeRates) are not derived in compact form, because
+// they are computed on lookup. Returns an error if the currency is not
+// supported by the bank or ErrReadOnlyBank if the bank is a snapshot.
+func (bank *Bank) SetCompactExchangeRates(baseIsoCode string) error {
+	if bank.readOnly {
+		return ErrReadOnlyBank
+	}
+	if baseIsoCode != "" {
+		_, err := bank.getCurrency(baseIsoCode)
+		if err != nil {
+			return err
+		}
+	}
+	bank.mu.Lock()
+	defer bank.mu.Unlock()
+	if baseIsoCode == bank.baseCurrencyIsoCode {
+		return nil
+	}
+	var table ExchangeRatesTable
+	if baseIsoCode != "" {
+		table = ExchangeRatesTable{baseIsoCode: bank.ExchangeRatesTable.baseRates(baseIsoCode)}
+	} else {
+		base := bank.baseCurrencyIsoCode
+		table = ExchangeRatesTableFromBase(base, bank.ExchangeRatesTable[base])
+	}
+	bank.baseCurrencyIsoCode = baseIsoCode
+	for isoCode := range bank.Currencies {
+		bank.ExchangeRatesTable[isoCode] = make(ExchangeRates)
+	}
+	bank.derivedExchangeRates = make(map[string]map[string]bool)
+	bank.eachSupportedExchangeRate(table, func(fromCurrencyIsoCode, toCurrencyIsoCode string, rate Decimal) {
+		bank.ExchangeRatesTable[fromCurrencyIsoCode][toCurrencyIsoCode] = rate
+	})
+	return nil
+}
+
+// CompactExchangeRatesBase returns the ISO code of the base currency of the
+// compact exchange rates table (see SetCompactExchangeRates), empty if the
+// bank stores the full table.
+func (bank *Bank) CompactExchangeRatesBase() string {
+	bank.mu.RLock()
+	defer bank.mu.RUnlock()
+	return bank.baseCurrencyIsoCode
+}
+
+// Private functions
+
+// expandedExchangeRatesTable returns a copy of the exchange rates table of the
+// bank, with all the cross rates derived from the base currency if the bank
+// stores the table in compact form. The caller must hold bank.mu.
+func (bank *Bank) expandedExchangeRatesTable() ExchangeRatesTable {
+	base := bank.baseCurrencyIsoCode
+	if base == "" {
+		return bank.ExchangeRatesTable.clone()
+	}
+	return ExchangeRatesTableFromBase(base, bank.ExchangeRatesTable[base])
+}
+
+// compactExchangeRate returns the exchange rate from fromCurrencyIsoCode to
+// toCurrencyIsoCode computed from the row of the base currency in table, and
+// the path through the base currency. Returns a nil path if the bank does not
+// store the table in compact form or the rate can not be computed. The caller
+// must hold bank.mu.
+func (bank *Bank) compactExchangeRate(table ExchangeRatesTable, fromCurrencyIsoCode, toCurrencyIsoCode string) (Decimal, []string) {
+	base := bank.baseCurrencyIsoCode
+	if base == "" {
+		return Decimal{}, nil
+	}
+	row := table[base]
+	if fromCurrencyIsoCode == base {
+		// The published rate has already been looked up
+		return Decimal{}, nil
+	}
+	fromRate := row[fromCurrencyIsoCode]
+	if fromRate.Sign() <= 0 {
+		return Decimal{}, nil
+	}
+	if toCurrencyIsoCode == base {
+		return fromRate.Inv(), []string{fromCurrencyIsoCode, toCurrencyIsoCode}
+	}
+	toRate := row[toCurrencyIsoCode]
+	if toRate.Sign() <= 0 {
+		return Decimal{}, nil
+	}
+	return toRate.Quo(fromRate), []string{fromCurrencyIsoCode, base, toCurrencyIsoCode}
+}
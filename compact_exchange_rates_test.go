@@ -0,0 +1,202 @@
+package money_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/pioz/money"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompactExchangeRates(t *testing.T) {
+	bank, err := money.NewBankWithOptions(
+		money.WithCurrencies([]money.Currency{money.EUR, money.USD, money.GBP, money.JPY}),
+		money.WithFetcher(func() (money.ExchangeRatesTable, error) {
+			return money.ExchangeRatesTableFromFloat(map[string]map[string]float64{
+				"EUR": {"USD": 1.25, "GBP": 0.8},
+				"JPY": {"EUR": 0.005},
+			}), nil
+		}),
+		money.WithCompactExchangeRates("EUR"),
+	)
+	assert.Nil(t, err)
+	assert.Equal(t, "EUR", bank.CompactExchangeRatesBase())
+
+	// Only the row of the base currency is stored
+	assert.Equal(t, money.ExchangeRates{
+		"USD": money.MustParseDecimal("1.25"),
+		"GBP": money.MustParseDecimal("0.8"),
+		"JPY": money.MustParseDecimal("200"),
+	}, bank.ExchangeRatesTable["EUR"])
+	assert.Empty(t, bank.ExchangeRatesTable["USD"])
+	assert.Empty(t, bank.ExchangeRatesTable["JPY"])
+
+	rate, path, err := bank.GetExchangeRatePath("EUR", "USD")
+	assert.Nil(t, err)
+	assert.Equal(t, "1.25", rate.String())
+	assert.Equal(t, []string{"EUR", "USD"}, path)
+	rate, path, err = bank.GetExchangeRatePath("USD", "EUR")
+	assert.Nil(t, err)
+	assert.Equal(t, "0.8", rate.String())
+	assert.Equal(t, []string{"USD", "EUR"}, path)
+	rate, path, err = bank.GetExchangeRatePath("USD", "GBP")
+	assert.Nil(t, err)
+	assert.Equal(t, "0.64", rate.String())
+	assert.Equal(t, []string{"USD", "EUR", "GBP"}, path)
+	rate, _, err = bank.GetExchangeRatePath("GBP", "JPY")
+	assert.Nil(t, err)
+	assert.Equal(t, "250", rate.String())
+
+	// Snapshots keep the compact form
+	snapshot := bank.Snapshot()
+	assert.Equal(t, "EUR", snapshot.CompactExchangeRatesBase())
+	rate, _, err = snapshot.GetExchangeRatePath("USD", "GBP")
+	assert.Nil(t, err)
+	assert.Equal(t, "0.64", rate.String())
+	err = snapshot.SetCompactExchangeRates("")
+	assert.True(t, errors.Is(err, money.ErrReadOnlyBank))
+	assert.Equal(t, "EUR", snapshot.CompactExchangeRatesBase())
+
+	// The full table is restored
+	err = bank.SetCompactExchangeRates("")
+	assert.Nil(t, err)
+	assert.Equal(t, "", bank.CompactExchangeRatesBase())
+	assert.Equal(t, "0.64", bank.ExchangeRatesTable["USD"]["GBP"].String())
+	assert.Equal(t, "250", bank.ExchangeRatesTable["GBP"]["JPY"].String())
+
+	err = bank.SetCompactExchangeRates("CHF")
+	assert.True(t, errors.Is(err, money.ErrUnsupportedCurrency))
+}
+
+func TestCompactExchangeRatesFromFullTable(t *testing.T) {
+	bank, err := money.NewBankFromStaticExchangeRatesTable([]money.Currency{money.EUR, money.USD, money.JPY}, money.ExchangeRatesTableFromFloat(map[string]map[string]float64{
+		"USD": {"EUR": 0.8, "JPY": 100},
+	}))
+	assert.Nil(t, err)
+	bank.SetInverseExchangeRates(true, false)
+	assert.True(t, bank.IsDerivedExchangeRate("EUR", "USD"))
+
+	err = bank.SetCompactExchangeRates("EUR")
+	assert.Nil(t, err)
+	assert.False(t, bank.IsDerivedExchangeRate("EUR", "USD"))
+	assert.Equal(t, 2, len(bank.ExchangeRatesTable["EUR"]))
+	assert.Empty(t, bank.ExchangeRatesTable["USD"])
+	rate, err := bank.GetExchangeRate("EUR", "JPY")
+	assert.Nil(t, err)
+	assert.Equal(t, 125.0, rate)
+
+	// Inverse exchange rates are not derived in compact form
+	err = bank.UpdateExchangeRatesTable()
+	assert.Nil(t, err)
+	assert.Empty(t, bank.ExchangeRatesTable["USD"])
+	assert.Empty(t, bank.ExchangeRatesTable["JPY"])
+}
+
+func TestCompactExchangeRatesValidationAndObservers(t *testing.T) {
+	table := money.ExchangeRatesTableFromFloat(map[string]map[string]float64{"EUR": {"USD": 1.25, "GBP": 0.8}})
+	var changes []money.ExchangeRateChange
+	bank, err := money.NewBankWithOptions(
+		money.WithCurrencies([]money.Currency{money.EUR, money.USD, money.GBP}),
+		money.WithFetcher(func() (money.ExchangeRatesTable, error) {
+			return table, nil
+		}),
+		money.WithCompactExchangeRates("EUR"),
+		money.WithLogger(nil),
+		money.WithValidationRules(money.ValidationRules{
+			MaxDeviation:       0.1,
+			RequiredCurrencies: []string{"EUR", "USD", "GBP"},
+		}),
+		money.WithObserver(func(oldTable, newTable money.ExchangeRatesTable, c []money.ExchangeRateChange) {
+			changes = c
+		}),
+	)
+	// The cross rates derived from the base currency cover the required
+	// currencies
+	assert.Nil(t, err)
+	assert.Equal(t, 6, len(changes))
+
+	// Observers get the changes of the cross rates too
+	table = money.ExchangeRatesTableFromFloat(map[string]map[string]float64{"EUR": {"USD": 1.25, "GBP": 0.78125}})
+	assert.Nil(t, bank.UpdateExchangeRatesTable())
+	assert.Equal(t, []string{"EUR→GBP", "GBP→EUR", "GBP→USD", "USD→GBP"}, changedPairs(changes))
+	assert.Equal(t, "1.6", changes[2].New.String())
+
+	// The deviation is checked also on the cross rates: USD→GBP changes by
+	// about 11%, EUR→USD and EUR→GBP by less than 10%
+	table = money.ExchangeRatesTableFromFloat(map[string]map[string]float64{"EUR": {"USD": 1.3, "GBP": 0.725}})
+	err = bank.UpdateExchangeRatesTable()
+	assert.True(t, errors.Is(err, money.ErrInvalidExchangeRatesTable))
+	assert.Equal(t, "invalid exchange rates table from fetch: GBP→USD rate 52/29 deviates too much from 1.6; USD→GBP rate 29/52 deviates too much from 0.625", err.Error())
+
+	// A missing rate of the base currency is reported as missing cross rates
+	table = money.ExchangeRatesTableFromFloat(map[string]map[string]float64{"EUR": {"USD": 1.25}})
+	err = bank.UpdateExchangeRatesTable()
+	assert.True(t, errors.Is(err, money.ErrInvalidExchangeRatesTable))
+	assert.Equal(t, "invalid exchange rates table from fetch: EUR→GBP rate is missing; GBP→EUR rate is missing; GBP→USD rate is missing; USD→GBP rate is missing", err.Error())
+}
+
+func changedPairs(changes []money.ExchangeRateChange) []string {
+	pairs := make([]string, 0, len(changes))
+	for _, change := range changes {
+		pairs = append(pairs, change.From+"→"+change.To)
+	}
+	return pairs
+}
+
+func benchmarkGetExchangeRate(b *testing.B, opts ...money.BankOption) {
+	table := allCurrenciesExchangeRatesTable()
+	opts = append(opts, money.WithFetcher(func() (money.ExchangeRatesTable, error) {
+		return table, nil
+	}))
+	bank, err := money.NewBankWithOptions(opts...)
+	if err != nil {
+		b.Fatal(err)
+	}
+	n := len(money.AllCurrencies)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		from := money.AllCurrencies[i%n].IsoCode
+		to := money.AllCurrencies[(i*7+1)%n].IsoCode
+		_, err = bank.GetExchangeRate(from, to)
+		if err != nil && from != to {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkGetExchangeRateFullTable(b *testing.B) {
+	benchmarkGetExchangeRate(b)
+}
+
+func BenchmarkGetExchangeRateCompactTable(b *testing.B) {
+	benchmarkGetExchangeRate(b, money.WithCompactExchangeRates("USD"))
+}
+
+func benchmarkUpdateExchangeRatesTable(b *testing.B, table money.ExchangeRatesTable, opts ...money.BankOption) {
+	opts = append(opts, money.WithFetcher(func() (money.ExchangeRatesTable, error) {
+		return table, nil
+	}))
+	bank, err := money.NewBankWithOptions(opts...)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		err = bank.UpdateExchangeRatesTable()
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkUpdateExchangeRatesTableFullTable(b *testing.B) {
+	benchmarkUpdateExchangeRatesTable(b, allCurrenciesExchangeRatesTable())
+}
+
+func BenchmarkUpdateExchangeRatesTableCompactTable(b *testing.B) {
+	// The fetch returns only the row of the base currency
+	table := money.ExchangeRatesTable{"USD": allCurrenciesExchangeRatesTable()["USD"]}
+	benchmarkUpdateExchangeRatesTable(b, table, money.WithCompactExchangeRates("USD"))
+}
@@ -0,0 +1,210 @@
+package money
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+)
+
+// Decimal is an exact rational number used to store and apply exchange rates
+// without the precision loss of float64. Exchange rates published as decimal
+// numbers, like 1.16, are stored exactly, so an exchange always gives the same
+// result. The zero value of Decimal is the number 0. A Decimal is immutable:
+// all operations return a new Decimal.
+type Decimal struct {
+	rat *big.Rat
+}
+
+// NewDecimal returns the Decimal num/denom. It panics if denom is zero.
+func NewDecimal(num, denom int64) Decimal {
+	return Decimal{rat: big.NewRat(num, denom)}
+}
+
+// NewDecimalFromFloat returns the Decimal of the shortest decimal
+// representation of f, so for example 1.2 becomes exactly 12/10 instead of
+// the binary approximation stored in the float64. It panics if f is NaN or
+// infinite (see DecimalFromFloat).
+func NewDecimalFromFloat(f float64) Decimal {
+	d, err := DecimalFromFloat(f)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+// DecimalFromFloat is like NewDecimalFromFloat but returns an error if f is
+// NaN or infinite.
+func DecimalFromFloat(f float64) (Decimal, error) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return Decimal{}, fmt.Errorf("invalid decimal %v", f)
+	}
+	return ParseDecimal(strconv.FormatFloat(f, 'g', -1, 64))
+}
+
+// ParseDecimal parses s and returns the corresponding Decimal. s can be given
+// as a decimal number, optionally with an exponent, like "1.16" or "1.5e-3",
+// or as a fraction like "7/6".
+func ParseDecimal(s string) (Decimal, error) {
+	rat, ok := new(big.Rat).SetString(s)
+	if !ok {
+		return Decimal{}, fmt.Errorf("invalid decimal %q", s)
+	}
+	return Decimal{rat: rat}, nil
+}
+
+// MustParseDecimal is like ParseDecimal but panics if s can not be parsed.
+func MustParseDecimal(s string) Decimal {
+	d, err := ParseDecimal(s)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+// Rat returns a copy of d as big.Rat.
+func (d Decimal) Rat() *big.Rat {
+	return new(big.Rat).Set(d.get())
+}
+
+// Float64 returns the nearest float64 value of d.
+func (d Decimal) Float64() float64 {
+	f, _ := d.get().Float64()
+	return f
+}
+
+// IsZero returns true if d is zero.
+func (d Decimal) IsZero() bool {
+	return d.Sign() == 0
+}
+
+// Sign returns -1 if d < 0, 0 if d == 0 and +1 if d > 0.
+func (d Decimal) Sign() int {
+	return d.get().Sign()
+}
+
+// Cmp compares d and e and returns -1 if d < e, 0 if d == e and +1 if d > e.
+func (d Decimal) Cmp(e Decimal) int {
+	return d.get().Cmp(e.get())
+}
+
+// Add returns d + e.
+func (d Decimal) Add(e Decimal) Decimal {
+	return Decimal{rat: new(big.Rat).Add(d.get(), e.get())}
+}
+
+// Sub returns d - e.
+func (d Decimal) Sub(e Decimal) Decimal {
+	return Decimal{rat: new(big.Rat).Sub(d.get(), e.get())}
+}
+
+// Mul returns d * e.
+func (d Decimal) Mul(e Decimal) Decimal {
+	return Decimal{rat: new(big.Rat).Mul(d.get(), e.get())}
+}
+
+// Quo returns d / e. It panics if e is zero.
+func (d Decimal) Quo(e Decimal) Decimal {
+	return Decimal{rat: new(big.Rat).Quo(d.get(), e.get())}
+}
+
+// Inv returns 1 / d. It panics if d is zero.
+func (d Decimal) Inv() Decimal {
+	return Decimal{rat: new(big.Rat).Inv(d.get())}
+}
+
+// String returns d as an exact decimal number, like "1.16", if it has a
+// finite decimal representation, otherwise as a fraction, like "7/6".
+func (d Decimal) String() string {
+	rat := d.get()
+	digits, finite := decimalDigits(rat.Denom())
+	if !finite {
+		return rat.RatString()
+	}
+	return rat.FloatString(digits)
+}
+
+// MarshalJSON implements the json.Marshaler interface. d is encoded as a JSON
+// number if it has a finite decimal representation, otherwise as a JSON
+// string with the fraction.
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	s := d.String()
+	if _, finite := decimalDigits(d.get().Denom()); !finite {
+		return json.Marshal(s)
+	}
+	return []byte(s), nil
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface. It accepts a JSON
+// number or a JSON string parsable by ParseDecimal. The number is parsed from
+// its text, so it does not lose precision.
+func (d *Decimal) UnmarshalJSON(data []byte) error {
+	s := string(data)
+	if bytes.HasPrefix(data, []byte{'"'}) {
+		err := json.Unmarshal(data, &s)
+		if err != nil {
+			return err
+		}
+	}
+	parsed, err := ParseDecimal(s)
+	if err != nil {
+		return err
+	}
+	*d = parsed
+	return nil
+}
+
+// GobEncode implements the gob.GobEncoder interface.
+func (d Decimal) GobEncode() ([]byte, error) {
+	return d.get().GobEncode()
+}
+
+// GobDecode implements the gob.GobDecoder interface.
+func (d *Decimal) GobDecode(data []byte) error {
+	rat := new(big.Rat)
+	err := rat.GobDecode(data)
+	if err != nil {
+		return err
+	}
+	d.rat = rat
+	return nil
+}
+
+// Private functions
+
+var zeroRat = new(big.Rat)
+
+func (d Decimal) get() *big.Rat {
+	if d.rat == nil {
+		return zeroRat
+	}
+	return d.rat
+}
+
+// decimalDigits returns the number of decimal digits of a fraction with
+// denominator denom, and false if the fraction has not a finite decimal
+// representation, that is if denom has prime factors other than 2 and 5.
+func decimalDigits(denom *big.Int) (int, bool) {
+	d := new(big.Int).Set(denom)
+	two, five := big.NewInt(2), big.NewInt(5)
+	twos, fives := 0, 0
+	mod := new(big.Int)
+	for d.Cmp(big.NewInt(1)) != 0 {
+		switch {
+		case mod.Mod(d, two).Sign() == 0:
+			d.Quo(d, two)
+			twos++
+		case mod.Mod(d, five).Sign() == 0:
+			d.Quo(d, five)
+			fives++
+		default:
+			return 0, false
+		}
+	}
+	if twos > fives {
+		return twos, true
+	}
+	return fives, true
+}
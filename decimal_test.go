@@ -0,0 +1,113 @@
+package money_test
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"math"
+	"testing"
+
+	"github.com/pioz/money"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseDecimal(t *testing.T) {
+	d, err := money.ParseDecimal("1.16")
+	assert.Nil(t, err)
+	assert.Equal(t, "1.16", d.String())
+	assert.Equal(t, 1.16, d.Float64())
+
+	d, err = money.ParseDecimal("7/6")
+	assert.Nil(t, err)
+	assert.Equal(t, "7/6", d.String())
+
+	d, err = money.ParseDecimal("1.5e-3")
+	assert.Nil(t, err)
+	assert.Equal(t, "0.0015", d.String())
+
+	_, err = money.ParseDecimal("one")
+	assert.NotNil(t, err)
+	assert.Equal(t, `invalid decimal "one"`, err.Error())
+
+	assert.Panics(t, func() { money.MustParseDecimal("one") })
+
+	var zero money.Decimal
+	assert.True(t, zero.IsZero())
+	assert.Equal(t, "0", zero.String())
+	assert.Equal(t, "1.2", money.NewDecimalFromFloat(1.2).String())
+	assert.Panics(t, func() { money.NewDecimalFromFloat(math.NaN()) })
+	_, err = money.DecimalFromFloat(math.Inf(1))
+	assert.EqualError(t, err, "invalid decimal +Inf")
+	assert.Equal(t, "0.25", money.NewDecimal(1, 4).String())
+}
+
+func TestDecimalArithmetic(t *testing.T) {
+	a := money.MustParseDecimal("0.1")
+	b := money.MustParseDecimal("0.2")
+	assert.Equal(t, "0.3", a.Add(b).String())
+	assert.Equal(t, "-0.1", a.Sub(b).String())
+	assert.Equal(t, "0.02", a.Mul(b).String())
+	assert.Equal(t, "0.5", a.Quo(b).String())
+	assert.Equal(t, "10", a.Inv().String())
+	assert.Equal(t, -1, a.Cmp(b))
+	assert.Equal(t, 1, b.Cmp(a))
+	assert.Equal(t, 0, a.Cmp(money.NewDecimal(1, 10)))
+	assert.Equal(t, -1, a.Sub(b).Sign())
+	assert.Equal(t, "1/10", a.Rat().RatString())
+}
+
+func TestDecimalJSON(t *testing.T) {
+	data, err := json.Marshal(money.ExchangeRates{"EUR": money.MustParseDecimal("0.86702"), "GBP": money.NewDecimal(1, 3)})
+	assert.Nil(t, err)
+	assert.Equal(t, `{"EUR":0.86702,"GBP":"1/3"}`, string(data))
+
+	var rates money.ExchangeRates
+	err = json.Unmarshal([]byte(`{"EUR":0.86702000000000000001,"GBP":"1/3"}`), &rates)
+	assert.Nil(t, err)
+	assert.Equal(t, "0.86702000000000000001", rates["EUR"].String())
+	assert.Equal(t, "1/3", rates["GBP"].String())
+
+	var invalid money.ExchangeRates
+	err = json.Unmarshal([]byte(`{"EUR":"abc"}`), &invalid)
+	assert.NotNil(t, err)
+
+	value, err := rates.Value()
+	assert.Nil(t, err)
+	var scanned money.ExchangeRates
+	err = scanned.Scan(value)
+	assert.Nil(t, err)
+	assert.Equal(t, "0.86702000000000000001", scanned["EUR"].String())
+}
+
+func TestDecimalGob(t *testing.T) {
+	buf := new(bytes.Buffer)
+	err := gob.NewEncoder(buf).Encode(money.ExchangeRates{"EUR": money.NewDecimal(7, 6), "USD": {}})
+	assert.Nil(t, err)
+
+	var rates money.ExchangeRates
+	err = gob.NewDecoder(buf).Decode(&rates)
+	assert.Nil(t, err)
+	assert.Equal(t, "7/6", rates["EUR"].String())
+	assert.True(t, rates["USD"].IsZero())
+}
+
+func TestExchangeDecimalPrecision(t *testing.T) {
+	bank, err := money.NewBankFromStaticExchangeRatesTable([]money.Currency{money.EUR, money.USD}, money.ExchangeRatesTable{
+		"EUR": {"USD": money.MustParseDecimal("1.25")},
+		"USD": {"EUR": money.MustParseDecimal("0.8")},
+	})
+	assert.Nil(t, err)
+
+	m, err := bank.NewMoney(9007199254740993, "EUR")
+	assert.Nil(t, err)
+	usd, err := m.ExchangeTo("USD")
+	assert.Nil(t, err)
+	assert.Equal(t, 11258999068426241, usd.Cents)
+	eur, err := usd.ExchangeTo("EUR")
+	assert.Nil(t, err)
+	assert.Equal(t, m.Cents, eur.Cents)
+
+	ex, err := m.ExchangeToWithDecimalRate("USD", money.MustParseDecimal("1.1"))
+	assert.Nil(t, err)
+	assert.Equal(t, 9907919180215092, ex.Cents)
+}
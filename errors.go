@@ -0,0 +1,105 @@
+package money
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	// ErrUnsupportedCurrency is the error matched by errors.Is when the bank
+	// does not support a currency. See UnsupportedCurrencyError.
+	ErrUnsupportedCurrency = errors.New("unsupported currency")
+	// ErrRateUnavailable is the error matched by errors.Is when the bank is not
+	// able to exchange a currency to another. See RateUnavailableError.
+	ErrRateUnavailable = errors.New("exchange rate unavailable")
+	// ErrBankMismatch is the error matched by errors.Is when an operation
+	// involves money of different banks. See BankMismatchError.
+	ErrBankMismatch = errors.New("currencies have different banks")
+	// ErrInvalidSplit is returned by Money.Split when the number of parts is
+	// not positive.
+	ErrInvalidSplit = errors.New("split must be higher than zero")
+	// ErrReadOnlyBank is returned when the exchange rates or the settings of a
+	// bank snapshot are modified (see Bank.Snapshot).
+	ErrReadOnlyBank = errors.New("bank is a read-only snapshot: it can not be modified")
+	// ErrStaleExchangeRates is the cause of the RateUnavailableError returned
+	// when the exchange rates table is older than the maximum staleness of the
+	// bank (see Bank.SetMaxStaleness).
+	ErrStaleExchangeRates = errors.New("exchange rates table is stale")
+	// ErrExchangeRatesNotAvailable is the cause of the RateUnavailableError
+	// returned when a bank started with WithNonBlockingStartup has not yet got
+	// any exchange rates table, neither from the fetch function nor from the
+	// cache or the seed.
+	ErrExchangeRatesNotAvailable = errors.New("exchange rates not yet available")
+	// ErrInvalidExchangeRatesTable is the error matched by errors.Is when the
+	// bank rejects an exchange rates table. See ValidationError.
+	ErrInvalidExchangeRatesTable = errors.New("invalid exchange rates table")
+	// ErrEmptyCache is returned by ExchangeRatesTableMemoryCache when no
+	// exchange rates table has been written yet.
+	ErrEmptyCache = errors.New("exchange rates table cache is empty")
+)
+
+// UnsupportedCurrencyError is returned when the bank does not support the
+// currency with ISO code Currency.
+type UnsupportedCurrencyError struct {
+	Currency string
+}
+
+func (e *UnsupportedCurrencyError) Error() string {
+	return fmt.Sprintf("bank does not support %s currency", e.Currency)
+}
+
+// Is returns true if target is ErrUnsupportedCurrency.
+func (e *UnsupportedCurrencyError) Is(target error) bool {
+	return target == ErrUnsupportedCurrency
+}
+
+// RateUnavailableError is returned when the bank is not able to exchange the
+// currency with ISO code From to the currency with ISO code To, because the
+// exchange rate is missing and it can not be derived.
+type RateUnavailableError struct {
+	From string
+	To   string
+	// Date of the historical exchange rate, nil for the current exchange rate.
+	Date *time.Time
+	// Underlying cause, if any.
+	Err error
+}
+
+func (e *RateUnavailableError) Error() string {
+	msg := fmt.Sprintf("bank does not support exchange from %s to %s", e.From, e.To)
+	if e.Date != nil {
+		msg = fmt.Sprintf("%s at %s", msg, dateKey(*e.Date))
+	}
+	if e.Err != nil {
+		msg = fmt.Sprintf("%s: %s", msg, e.Err)
+	}
+	return msg
+}
+
+// Is returns true if target is ErrRateUnavailable.
+func (e *RateUnavailableError) Is(target error) bool {
+	return target == ErrRateUnavailable
+}
+
+// Unwrap returns the underlying cause.
+func (e *RateUnavailableError) Unwrap() error {
+	return e.Err
+}
+
+// BankMismatchError is returned when an operation involves money in the
+// currency with ISO code Currency and money in the currency with ISO code
+// OtherCurrency created by different banks.
+type BankMismatchError struct {
+	Currency      string
+	OtherCurrency string
+}
+
+func (e *BankMismatchError) Error() string {
+	return "currencies have different banks: operation between currencies can be done only between currencies of the same bank"
+}
+
+// Is returns true if target is ErrBankMismatch.
+func (e *BankMismatchError) Is(target error) bool {
+	return target == ErrBankMismatch
+}
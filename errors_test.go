@@ -0,0 +1,71 @@
+package money_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/pioz/money"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnsupportedCurrencyError(t *testing.T) {
+	_, err := money.NewMoney(100, "XLN")
+	assert.True(t, errors.Is(err, money.ErrUnsupportedCurrency))
+	assert.False(t, errors.Is(err, money.ErrRateUnavailable))
+	var currencyErr *money.UnsupportedCurrencyError
+	assert.True(t, errors.As(err, &currencyErr))
+	assert.Equal(t, "XLN", currencyErr.Currency)
+	assert.Equal(t, "bank does not support XLN currency", err.Error())
+}
+
+func TestRateUnavailableError(t *testing.T) {
+	m, err := money.NewMoney(100, "EUR")
+	assert.Nil(t, err)
+
+	_, err = m.ExchangeTo("USD")
+	assert.True(t, errors.Is(err, money.ErrRateUnavailable))
+	var rateErr *money.RateUnavailableError
+	assert.True(t, errors.As(err, &rateErr))
+	assert.Equal(t, "EUR", rateErr.From)
+	assert.Equal(t, "USD", rateErr.To)
+	assert.Nil(t, rateErr.Date)
+	assert.Nil(t, errors.Unwrap(err))
+
+	date := time.Date(2021, time.October, 15, 0, 0, 0, 0, time.UTC)
+	_, err = m.ExchangeToAt("USD", date)
+	assert.True(t, errors.Is(err, money.ErrRateUnavailable))
+	assert.True(t, errors.As(err, &rateErr))
+	assert.Equal(t, date, *rateErr.Date)
+
+	cause := errors.New("provider is down")
+	err = &money.RateUnavailableError{From: "EUR", To: "USD", Err: cause}
+	assert.True(t, errors.Is(err, cause))
+	assert.Equal(t, "bank does not support exchange from EUR to USD: provider is down", err.Error())
+}
+
+func TestBankMismatchError(t *testing.T) {
+	bank, err := money.NewBankFromStaticExchangeRatesTable([]money.Currency{money.EUR, money.USD}, nil)
+	assert.Nil(t, err)
+	m1, err := bank.NewMoney(100, "EUR")
+	assert.Nil(t, err)
+	m2, err := money.NewMoney(100, "USD")
+	assert.Nil(t, err)
+
+	_, err = m1.Add(m2)
+	assert.True(t, errors.Is(err, money.ErrBankMismatch))
+	var mismatchErr *money.BankMismatchError
+	assert.True(t, errors.As(err, &mismatchErr))
+	assert.Equal(t, "EUR", mismatchErr.Currency)
+	assert.Equal(t, "USD", mismatchErr.OtherCurrency)
+}
+
+func TestSentinelErrors(t *testing.T) {
+	m, err := money.NewMoney(100, "EUR")
+	assert.Nil(t, err)
+	_, err = m.Split(-1)
+	assert.True(t, errors.Is(err, money.ErrInvalidSplit))
+
+	err = money.DefaultBank.Snapshot().UpdateExchangeRatesTable()
+	assert.True(t, errors.Is(err, money.ErrReadOnlyBank))
+}
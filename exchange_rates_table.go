@@ -1,6 +1,7 @@
 package money
 
 import (
+	"bufio"
 	"bytes"
 	"database/sql/driver"
 	"encoding/gob"
@@ -12,30 +13,58 @@ import (
 
 // ExchangeRates is a map of currency ISO code to the exchange rate. See
 // ExchangeRatesTable type for more details.
-type ExchangeRates map[string]float64
+type ExchangeRates map[string]Decimal
 
 // ExchangeRatesTable represents an exchange rates table, a map of currency ISO
 // code to a map of currency ISO code to the exchange rate.
 //
-//   table := map[string]map[string]float64{
-//     "USD": { "EUR": 0.87, "GBP": 0.74 },
-//     "EUR": { "USD": 1.16, "GBP": 0.86 },
-//     "GBP": { "USD": 1.35, "EUR": 1.17 },
-//   }
+//	table := ExchangeRatesTableFromFloat(map[string]map[string]float64{
+//	  "USD": { "EUR": 0.87, "GBP": 0.74 },
+//	  "EUR": { "USD": 1.16, "GBP": 0.86 },
+//	  "GBP": { "USD": 1.35, "EUR": 1.17 },
+//	})
 //
 // In this example to convert 1 USD to 1 EUR you have to multiply 1 * 0.87. So
 // to get the exchange rate to convert c1 into c2 you have to access the map
 // with
-//    table[c1][c2]
+//
+//	table[c1][c2]
+//
+// Exchange rates are stored as Decimal, so they are exact.
 type ExchangeRatesTable map[string]ExchangeRates
 
-// Scan scan value into Json, implements sql.Scanner interface.
+// ExchangeRatesTableFromFloat is a conveniently function to create an exchange
+// rates table from a map of float64 exchange rates. Each exchange rate is
+// converted to Decimal with NewDecimalFromFloat. Exchange rates that are NaN
+// or infinite, for example returned by a broken provider, become zero: the
+// bank does not exchange with a zero exchange rate, and the validation rules
+// (see ValidationRules) reject it.
+func ExchangeRatesTableFromFloat(table map[string]map[string]float64) ExchangeRatesTable {
+	result := make(ExchangeRatesTable, len(table))
+	for fromCurrencyIsoCode, rates := range table {
+		result[fromCurrencyIsoCode] = make(ExchangeRates, len(rates))
+		for toCurrencyIsoCode, rate := range rates {
+			// A non-finite rate is kept as zero, so that it is not missing
+			result[fromCurrencyIsoCode][toCurrencyIsoCode], _ = DecimalFromFloat(rate)
+		}
+	}
+	return result
+}
+
+// Scan scan value into Json, implements sql.Scanner interface. value can be a
+// []byte or a string, as returned by the drivers for text columns, or nil,
+// that is scanned as nil exchange rates (see Value).
 func (rates *ExchangeRates) Scan(value interface{}) error {
-	bytes, ok := value.([]byte)
-	if !ok {
-		return errors.New(fmt.Sprint("Failed to unmarshal ExchangeRates JSON value:", value))
+	switch v := value.(type) {
+	case nil:
+		*rates = nil
+		return nil
+	case string:
+		return json.Unmarshal([]byte(v), rates)
+	case []byte:
+		return json.Unmarshal(v, rates)
 	}
-	return json.Unmarshal(bytes, rates)
+	return errors.New(fmt.Sprint("Failed to unmarshal ExchangeRates JSON value:", value))
 }
 
 // Value return json value, implement driver.Valuer interface.
@@ -46,23 +75,70 @@ func (rates ExchangeRates) Value() (driver.Value, error) {
 	return json.Marshal(rates)
 }
 
-// Read implements the standard Read interface: fill b from table.
+// WriteTo implements the io.WriterTo interface: it writes the gob encoding of
+// the table to w. Returns the number of bytes written.
+func (table ExchangeRatesTable) WriteTo(w io.Writer) (int64, error) {
+	cw := &countingWriter{w: w}
+	err := gob.NewEncoder(cw).Encode(table)
+	return cw.n, err
+}
+
+// ReadFrom implements the io.ReaderFrom interface: it loads the table from the
+// gob encoding read from r. Tables encoded with float64 exchange rates by
+// previous versions of the package are still loaded. Returns the number of
+// bytes of the encoding. Unless r is a *bufio.Reader, r may be read beyond the
+// end of the encoding.
+func (table *ExchangeRatesTable) ReadFrom(r io.Reader) (int64, error) {
+	br, ok := r.(*bufio.Reader)
+	if !ok {
+		br = bufio.NewReader(r)
+	}
+	// gob does not read ahead from an io.ByteReader, so cr counts only the
+	// bytes of the encoding
+	cr := &countingByteReader{r: br}
+	var decoded ExchangeRatesTable
+	if hasFloatGobHeader(br) {
+		var floatTable map[string]map[string]float64
+		err := gob.NewDecoder(cr).Decode(&floatTable)
+		if err != nil {
+			return cr.n, err
+		}
+		decoded = ExchangeRatesTableFromFloat(floatTable)
+	} else {
+		err := gob.NewDecoder(cr).Decode(&decoded)
+		if err != nil {
+			return cr.n, err
+		}
+	}
+	*table = decoded
+	return cr.n, nil
+}
+
+// Read fills b with the gob encoding of the table. Returns io.ErrShortBuffer
+// if b is too small to contain the whole encoding.
+//
+// Deprecated: Read can not encode a table in several calls, so it is not a
+// proper io.Reader. Use WriteTo.
 func (table *ExchangeRatesTable) Read(b []byte) (int, error) {
 	buf := new(bytes.Buffer)
-	enc := gob.NewEncoder(buf)
-	err := enc.Encode(table)
+	_, err := table.WriteTo(buf)
 	if err != nil {
 		return 0, err
 	}
+	if buf.Len() > len(b) {
+		return 0, io.ErrShortBuffer
+	}
 	n := copy(b, buf.Bytes())
 	return n, io.EOF
 }
 
-// Write implements the standard Write interface: load table from b.
+// Write loads the table from b, that must contain the whole gob encoding of a
+// table.
+//
+// Deprecated: Write can not decode a table written in several calls, so it is
+// not a proper io.Writer. Use ReadFrom.
 func (table *ExchangeRatesTable) Write(b []byte) (int, error) {
-	reader := bytes.NewReader(b)
-	dec := gob.NewDecoder(reader)
-	err := dec.Decode(table)
+	_, err := table.ReadFrom(bytes.NewReader(b))
 	if err != nil {
 		return 0, err
 	}
@@ -75,3 +151,126 @@ type ExchangeRatesTableCache interface {
 	Read() (ExchangeRatesTable, error)
 	Write(table ExchangeRatesTable) error
 }
+
+// Private functions
+
+func (table ExchangeRatesTable) clone() ExchangeRatesTable {
+	result := make(ExchangeRatesTable, len(table))
+	for fromCurrencyIsoCode, rates := range table {
+		result[fromCurrencyIsoCode] = make(ExchangeRates, len(rates))
+		for toCurrencyIsoCode, rate := range rates {
+			result[fromCurrencyIsoCode][toCurrencyIsoCode] = rate
+		}
+	}
+	return result
+}
+
+// countExchangeRates returns the number of exchange rates in table.
+func (table ExchangeRatesTable) countExchangeRates() int {
+	count := 0
+	for _, rates := range table {
+		count += len(rates)
+	}
+	return count
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (cw *countingWriter) Write(b []byte) (int, error) {
+	n, err := cw.w.Write(b)
+	cw.n += int64(n)
+	return n, err
+}
+
+type countingByteReader struct {
+	r *bufio.Reader
+	n int64
+}
+
+func (cr *countingByteReader) Read(b []byte) (int, error) {
+	n, err := cr.r.Read(b)
+	cr.n += int64(n)
+	return n, err
+}
+
+func (cr *countingByteReader) ReadByte() (byte, error) {
+	b, err := cr.r.ReadByte()
+	if err == nil {
+		cr.n++
+	}
+	return b, err
+}
+
+// gobFloatMapType is the gob encoding of the key and element types of a map
+// type definition with string keys and float64 elements.
+var gobFloatMapType = []byte{0x01, 0x0c, 0x01, 0x08, 0x00}
+
+// hasFloatGobHeader returns true if the type definitions at the start of the
+// gob stream in br define a map of float64, as the tables encoded by previous
+// versions of the package. The stream is peeked, not consumed.
+func hasFloatGobHeader(br *bufio.Reader) bool {
+	offset := 0
+	for {
+		length, size, ok := peekGobUint(br, offset)
+		if !ok {
+			return false
+		}
+		message, err := br.Peek(offset + size + int(length))
+		if err != nil || length == 0 {
+			return false
+		}
+		message = message[offset+size:]
+		// Type definitions have a negative type id, whose gob encoding has the
+		// lowest bit set, and they precede the value
+		typeID, idSize, ok := decodeGobUint(message)
+		if !ok || typeID&1 == 0 {
+			return false
+		}
+		if bytes.Contains(message[idSize:], gobFloatMapType) {
+			return true
+		}
+		offset += size + int(length)
+	}
+}
+
+// peekGobUint returns the gob encoded unsigned integer at offset in br and its
+// size in bytes.
+func peekGobUint(br *bufio.Reader, offset int) (uint64, int, bool) {
+	b, err := br.Peek(offset + 1)
+	if err != nil {
+		return 0, 0, false
+	}
+	size := 1
+	if b[offset] >= 0x80 {
+		size += int(-int8(b[offset]))
+	}
+	b, err = br.Peek(offset + size)
+	if err != nil {
+		return 0, 0, false
+	}
+	return decodeGobUint(b[offset:])
+}
+
+// decodeGobUint decodes the gob encoded unsigned integer at the start of b: a
+// single byte for values below 128, otherwise the negated byte count followed
+// by the big-endian value.
+func decodeGobUint(b []byte) (uint64, int, bool) {
+	if len(b) == 0 {
+		return 0, 0, false
+	}
+	if b[0] < 0x80 {
+		return uint64(b[0]), 1, true
+	}
+	size := int(-int8(b[0]))
+	if size > 8 || len(b) < 1+size {
+		return 0, 0, false
+	}
+	var x uint64
+	for _, c := range b[1 : 1+size] {
+		x = x<<8 | uint64(c)
+	}
+	return x, 1 + size, true
+}
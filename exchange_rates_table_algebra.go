@@ -0,0 +1,165 @@
+package money
+
+import "sort"
+
+// MergePrecedence is the rule used by ExchangeRatesTable.Merge when both
+// tables have the exchange rate of the same pair of currencies.
+type MergePrecedence int
+
+const (
+	// KeepExisting keeps the exchange rate of the table that Merge is called
+	// on.
+	KeepExisting MergePrecedence = iota
+	// PreferOther uses the exchange rate of the table passed to Merge.
+	PreferOther
+)
+
+// ExchangeRatesTableFromBase derives the full exchange rates table of base
+// and of the currencies of rates, where rates are the exchange rates from
+// base. The exchange rate from X to Y is rates[Y] / rates[X], so for example
+// with base EUR and rates EUR→USD 1.16 and EUR→GBP 0.86, the table has also
+// the exchange rates USD→EUR 1/1.16 and USD→GBP 0.86/1.16. Exchange rates that
+// are not positive are ignored.
+func ExchangeRatesTableFromBase(base string, rates ExchangeRates) ExchangeRatesTable {
+	row := ExchangeRates{base: NewDecimal(1, 1)}
+	for isoCode, rate := range rates {
+		if isoCode != base && rate.Sign() > 0 {
+			row[isoCode] = rate
+		}
+	}
+	result := make(ExchangeRatesTable, len(row))
+	for fromCurrencyIsoCode, fromRate := range row {
+		result[fromCurrencyIsoCode] = make(ExchangeRates, len(row)-1)
+		for toCurrencyIsoCode, toRate := range row {
+			if fromCurrencyIsoCode != toCurrencyIsoCode {
+				result[fromCurrencyIsoCode][toCurrencyIsoCode] = toRate.Quo(fromRate)
+			}
+		}
+	}
+	return result
+}
+
+// Merge returns a new table with the exchange rates of table and other. When
+// both tables have the exchange rate of the same pair of currencies, the rate
+// is chosen with precedence.
+func (table ExchangeRatesTable) Merge(other ExchangeRatesTable, precedence MergePrecedence) ExchangeRatesTable {
+	result := table.clone()
+	for fromCurrencyIsoCode, rates := range other {
+		if result[fromCurrencyIsoCode] == nil {
+			result[fromCurrencyIsoCode] = make(ExchangeRates, len(rates))
+		}
+		for toCurrencyIsoCode, rate := range rates {
+			if _, found := result[fromCurrencyIsoCode][toCurrencyIsoCode]; found && precedence == KeepExisting {
+				continue
+			}
+			result[fromCurrencyIsoCode][toCurrencyIsoCode] = rate
+		}
+	}
+	return result
+}
+
+// Invert returns a new table with the inverse of each exchange rate of table:
+// the exchange rate from X to Y becomes the exchange rate from Y to X, 1/rate.
+// Exchange rates that are not positive are ignored.
+func (table ExchangeRatesTable) Invert() ExchangeRatesTable {
+	result := make(ExchangeRatesTable, len(table))
+	for fromCurrencyIsoCode, rates := range table {
+		for toCurrencyIsoCode, rate := range rates {
+			if rate.Sign() <= 0 {
+				continue
+			}
+			if result[toCurrencyIsoCode] == nil {
+				result[toCurrencyIsoCode] = make(ExchangeRates)
+			}
+			result[toCurrencyIsoCode][fromCurrencyIsoCode] = rate.Inv()
+		}
+	}
+	return result
+}
+
+// Rebase returns a new table with the exchange rates between base and each
+// currency of table reachable from base, and between each pair of these
+// currencies, all derived from the exchange rates from base (see
+// ExchangeRatesTableFromBase). The exchange rate from base to X is the
+// published one, otherwise the inverse of the exchange rate from X to base,
+// otherwise it is computed along the path with the fewest exchanges from base
+// to X, using published or inverse exchange rates. Currencies that can not be
+// reached from base are omitted, so the table is empty if base does not have
+// any positive exchange rate in table.
+func (table ExchangeRatesTable) Rebase(base string) ExchangeRatesTable {
+	rates := table.baseRates(base)
+	if len(rates) == 0 {
+		return make(ExchangeRatesTable)
+	}
+	return ExchangeRatesTableFromBase(base, rates)
+}
+
+// Subset returns a new table with only the exchange rates between the
+// currencies with ISO codes isoCodes.
+func (table ExchangeRatesTable) Subset(isoCodes ...string) ExchangeRatesTable {
+	keep := make(map[string]bool, len(isoCodes))
+	for _, isoCode := range isoCodes {
+		keep[isoCode] = true
+	}
+	result := make(ExchangeRatesTable)
+	for fromCurrencyIsoCode, rates := range table {
+		if !keep[fromCurrencyIsoCode] {
+			continue
+		}
+		result[fromCurrencyIsoCode] = make(ExchangeRates)
+		for toCurrencyIsoCode, rate := range rates {
+			if keep[toCurrencyIsoCode] {
+				result[fromCurrencyIsoCode][toCurrencyIsoCode] = rate
+			}
+		}
+	}
+	return result
+}
+
+// Diff returns the exchange rates that are different in table and other,
+// sorted by currency ISO codes, with Old from table and New from other. See
+// ExchangeRateChange.AbsoluteChange and ExchangeRateChange.RelativeChange.
+func (table ExchangeRatesTable) Diff(other ExchangeRatesTable) []ExchangeRateChange {
+	return diffExchangeRatesTables(table, other)
+}
+
+// Private functions
+
+// baseRates returns the exchange rates from base to each currency of table
+// reachable from base (see Rebase), using a breadth-first search over the
+// published and inverse exchange rates of table.
+func (table ExchangeRatesTable) baseRates(base string) ExchangeRates {
+	graph := table.Invert()
+	for fromCurrencyIsoCode, rates := range table {
+		for toCurrencyIsoCode, rate := range rates {
+			if rate.Sign() <= 0 || fromCurrencyIsoCode == toCurrencyIsoCode {
+				continue
+			}
+			if graph[fromCurrencyIsoCode] == nil {
+				graph[fromCurrencyIsoCode] = make(ExchangeRates)
+			}
+			// Published exchange rates take precedence over the inverse ones
+			graph[fromCurrencyIsoCode][toCurrencyIsoCode] = rate
+		}
+	}
+	rates := ExchangeRates{base: NewDecimal(1, 1)}
+	queue := []string{base}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		neighbours := make([]string, 0, len(graph[current]))
+		for isoCode := range graph[current] {
+			neighbours = append(neighbours, isoCode)
+		}
+		sort.Strings(neighbours)
+		for _, isoCode := range neighbours {
+			if _, visited := rates[isoCode]; visited {
+				continue
+			}
+			rates[isoCode] = rates[current].Mul(graph[current][isoCode])
+			queue = append(queue, isoCode)
+		}
+	}
+	delete(rates, base)
+	return rates
+}
@@ -0,0 +1,102 @@
+package money_test
+
+import (
+	"testing"
+
+	"github.com/pioz/money"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExchangeRatesTableFromBase(t *testing.T) {
+	table := money.ExchangeRatesTableFromBase("EUR", money.ExchangeRates{
+		"USD": money.MustParseDecimal("1.25"),
+		"GBP": money.MustParseDecimal("0.8"),
+		"JPY": money.MustParseDecimal("0"),
+		"EUR": money.MustParseDecimal("2"),
+	})
+	assert.Equal(t, money.ExchangeRatesTable{
+		"EUR": {"USD": money.MustParseDecimal("1.25"), "GBP": money.MustParseDecimal("0.8")},
+		"USD": {"EUR": money.MustParseDecimal("0.8"), "GBP": money.MustParseDecimal("0.64")},
+		"GBP": {"EUR": money.MustParseDecimal("1.25"), "USD": money.MustParseDecimal("1.5625")},
+	}, table)
+}
+
+func TestExchangeRatesTableMerge(t *testing.T) {
+	table := money.ExchangeRatesTableFromFloat(map[string]map[string]float64{"EUR": {"USD": 1.2}})
+	other := money.ExchangeRatesTableFromFloat(map[string]map[string]float64{"EUR": {"USD": 1.3, "JPY": 130}, "USD": {"EUR": 0.8}})
+
+	merged := table.Merge(other, money.KeepExisting)
+	assert.Equal(t, money.ExchangeRatesTableFromFloat(map[string]map[string]float64{"EUR": {"USD": 1.2, "JPY": 130}, "USD": {"EUR": 0.8}}), merged)
+	merged = table.Merge(other, money.PreferOther)
+	assert.Equal(t, other, merged)
+
+	// The inputs are not modified
+	merged["EUR"]["GBP"] = money.NewDecimal(86, 100)
+	assert.Equal(t, money.ExchangeRatesTableFromFloat(map[string]map[string]float64{"EUR": {"USD": 1.2}}), table)
+	assert.Equal(t, money.ExchangeRatesTableFromFloat(map[string]map[string]float64{"EUR": {"USD": 1.3, "JPY": 130}, "USD": {"EUR": 0.8}}), other)
+}
+
+func TestExchangeRatesTableInvert(t *testing.T) {
+	table := money.ExchangeRatesTableFromFloat(map[string]map[string]float64{"EUR": {"USD": 1.25, "JPY": 0}})
+	assert.Equal(t, money.ExchangeRatesTable{"USD": {"EUR": money.MustParseDecimal("0.8")}}, table.Invert())
+}
+
+func TestExchangeRatesTableRebase(t *testing.T) {
+	table := money.ExchangeRatesTableFromFloat(map[string]map[string]float64{
+		"EUR": {"USD": 1.25, "GBP": 0.8},
+		"JPY": {"USD": 0.01},
+		"GBP": {"NOK": 12},
+		"CHF": {"SEK": 10},
+	})
+	rebased := table.Rebase("USD")
+	assert.Equal(t, money.ExchangeRates{
+		"EUR": money.MustParseDecimal("0.8"),
+		"JPY": money.MustParseDecimal("100"),
+		"GBP": money.MustParseDecimal("0.64"),
+		"NOK": money.MustParseDecimal("7.68"),
+	}, rebased["USD"])
+	assert.Equal(t, 5, len(rebased))
+	for _, rates := range rebased {
+		assert.Equal(t, 4, len(rates))
+	}
+	assert.Equal(t, "1.25", rebased["EUR"]["USD"].String())
+	assert.Equal(t, "1.5625", rebased["GBP"]["USD"].String())
+	assert.Equal(t, "156.25", rebased["GBP"]["JPY"].String())
+	assert.Equal(t, "12", rebased["GBP"]["NOK"].String())
+	assert.Nil(t, rebased["CHF"])
+
+	// A currency without exchange rates gives an empty table
+	assert.Equal(t, money.ExchangeRatesTable{}, table.Rebase("XXX"))
+	assert.Equal(t, money.ExchangeRatesTable{}, money.ExchangeRatesTableFromFloat(map[string]map[string]float64{"XXX": {"USD": 0}}).Rebase("XXX"))
+}
+
+func TestExchangeRatesTableSubset(t *testing.T) {
+	table := money.ExchangeRatesTableFromFloat(map[string]map[string]float64{
+		"EUR": {"USD": 1.2, "JPY": 130},
+		"JPY": {"EUR": 0.0077},
+		"USD": {"EUR": 0.8},
+	})
+	assert.Equal(t, money.ExchangeRatesTableFromFloat(map[string]map[string]float64{
+		"EUR": {"USD": 1.2},
+		"USD": {"EUR": 0.8},
+	}), table.Subset("EUR", "USD", "GBP"))
+	assert.Equal(t, 3, len(table["EUR"])+len(table["JPY"]))
+}
+
+func TestExchangeRatesTableDiff(t *testing.T) {
+	table := money.ExchangeRatesTableFromFloat(map[string]map[string]float64{"EUR": {"USD": 1.25, "JPY": 130}})
+	other := money.ExchangeRatesTableFromFloat(map[string]map[string]float64{"EUR": {"USD": 1.5, "GBP": 0.86}, "USD": {"EUR": 0.8}})
+
+	changes := table.Diff(other)
+	assert.Equal(t, 4, len(changes))
+	assert.Equal(t, money.ExchangeRateChange{From: "EUR", To: "GBP", New: money.MustParseDecimal("0.86")}, changes[0])
+	assert.Equal(t, money.ExchangeRateChange{From: "EUR", To: "JPY", Old: money.MustParseDecimal("130")}, changes[1])
+	assert.Equal(t, "EUR", changes[2].From)
+	assert.Equal(t, "USD", changes[2].To)
+	assert.Equal(t, "0.25", changes[2].AbsoluteChange().String())
+	assert.Equal(t, 0.2, changes[2].RelativeChange())
+	assert.Equal(t, "-130", changes[1].AbsoluteChange().String())
+	assert.Equal(t, "USD", changes[3].From)
+
+	assert.Empty(t, table.Diff(table))
+}
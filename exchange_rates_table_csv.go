@@ -0,0 +1,285 @@
+package money
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// CSVFormat is the layout of an exchange rates table in CSV.
+type CSVFormat int
+
+const (
+	// CSVLong has a row for each exchange rate with the columns from, to and
+	// rate, after the header row "from,to,rate":
+	//
+	//	from,to,rate
+	//	EUR,USD,1.16
+	//	USD,EUR,0.87
+	CSVLong CSVFormat = iota
+	// CSVMatrix has a header row with the currencies to convert to and a row
+	// for each currency to convert from, that starts with the currency. An
+	// empty cell means that the exchange rate is missing, and the cells of a
+	// currency to itself are ignored. The first cell of the header row is
+	// ignored.
+	//
+	//	,EUR,USD
+	//	EUR,,1.16
+	//	USD,0.87,
+	CSVMatrix
+)
+
+// CSVError is returned when a CSV exchange rates table is not valid. Line and
+// Column are 1-based: Column is the number of the field in the record, as in
+// a spreadsheet. Errors of the CSV syntax are returned as *csv.ParseError.
+type CSVError struct {
+	Line   int
+	Column int
+	Err    error
+}
+
+func (e *CSVError) Error() string {
+	return fmt.Sprintf("line %d, column %d: %s", e.Line, e.Column, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *CSVError) Unwrap() error {
+	return e.Err
+}
+
+// ReadExchangeRatesTableCSV reads an exchange rates table from r in the given
+// CSV format. The ISO codes must be of currencies, nil means AllCurrencies,
+// otherwise a CSVError wrapping an UnsupportedCurrencyError is returned. The
+// exchange rates are parsed with ParseDecimal and must be positive.
+func ReadExchangeRatesTableCSV(r io.Reader, format CSVFormat, currencies []Currency) (ExchangeRatesTable, error) {
+	if currencies == nil {
+		currencies = AllCurrencies
+	}
+	reader := &csvTableReader{csv: csv.NewReader(r), supported: make(map[string]bool, len(currencies))}
+	reader.csv.TrimLeadingSpace = true
+	for _, currency := range currencies {
+		reader.supported[currency.IsoCode] = true
+	}
+	switch format {
+	case CSVLong:
+		reader.csv.FieldsPerRecord = 3
+		return reader.readLong()
+	case CSVMatrix:
+		return reader.readMatrix()
+	}
+	return nil, fmt.Errorf("unknown CSV format %d", format)
+}
+
+// WriteCSV writes the table to w in the given CSV format. Currencies are
+// sorted by ISO code, so the same table is always written in the same way.
+func (table ExchangeRatesTable) WriteCSV(w io.Writer, format CSVFormat) error {
+	writer := csv.NewWriter(w)
+	var err error
+	switch format {
+	case CSVLong:
+		err = table.writeLongCSV(writer)
+	case CSVMatrix:
+		err = table.writeMatrixCSV(writer)
+	default:
+		return fmt.Errorf("unknown CSV format %d", format)
+	}
+	if err != nil {
+		return err
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// Private functions
+
+func (table ExchangeRatesTable) writeLongCSV(writer *csv.Writer) error {
+	err := writer.Write([]string{"from", "to", "rate"})
+	if err != nil {
+		return err
+	}
+	fromCurrencies := make(map[string]bool, len(table))
+	for fromCurrencyIsoCode := range table {
+		fromCurrencies[fromCurrencyIsoCode] = true
+	}
+	for _, fromCurrencyIsoCode := range sortedIsoCodes(fromCurrencies) {
+		rates := table[fromCurrencyIsoCode]
+		toCurrencies := make(map[string]bool, len(rates))
+		for toCurrencyIsoCode := range rates {
+			toCurrencies[toCurrencyIsoCode] = true
+		}
+		for _, toCurrencyIsoCode := range sortedIsoCodes(toCurrencies) {
+			err = writer.Write([]string{fromCurrencyIsoCode, toCurrencyIsoCode, rates[toCurrencyIsoCode].String()})
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (table ExchangeRatesTable) writeMatrixCSV(writer *csv.Writer) error {
+	toCurrencies := make(map[string]bool)
+	for _, rates := range table {
+		for toCurrencyIsoCode := range rates {
+			toCurrencies[toCurrencyIsoCode] = true
+		}
+	}
+	header := append([]string{""}, sortedIsoCodes(toCurrencies)...)
+	err := writer.Write(header)
+	if err != nil {
+		return err
+	}
+	fromCurrencies := make(map[string]bool, len(table))
+	for fromCurrencyIsoCode := range table {
+		fromCurrencies[fromCurrencyIsoCode] = true
+	}
+	for _, fromCurrencyIsoCode := range sortedIsoCodes(fromCurrencies) {
+		record := make([]string, len(header))
+		record[0] = fromCurrencyIsoCode
+		for i, toCurrencyIsoCode := range header[1:] {
+			if rate, found := table[fromCurrencyIsoCode][toCurrencyIsoCode]; found {
+				record[i+1] = rate.String()
+			}
+		}
+		err = writer.Write(record)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func sortedIsoCodes(set map[string]bool) []string {
+	isoCodes := make([]string, 0, len(set))
+	for isoCode := range set {
+		isoCodes = append(isoCodes, isoCode)
+	}
+	sort.Strings(isoCodes)
+	return isoCodes
+}
+
+type csvTableReader struct {
+	csv       *csv.Reader
+	supported map[string]bool
+}
+
+func (reader *csvTableReader) errorAt(column int, err error) error {
+	line, _ := reader.csv.FieldPos(column - 1)
+	return &CSVError{Line: line, Column: column, Err: err}
+}
+
+func (reader *csvTableReader) currency(record []string, column int) (string, error) {
+	isoCode := strings.TrimSpace(record[column-1])
+	if !reader.supported[isoCode] {
+		return "", reader.errorAt(column, &UnsupportedCurrencyError{Currency: isoCode})
+	}
+	return isoCode, nil
+}
+
+func (reader *csvTableReader) rate(record []string, column int) (Decimal, error) {
+	rate, err := ParseDecimal(strings.TrimSpace(record[column-1]))
+	if err != nil {
+		return Decimal{}, reader.errorAt(column, err)
+	}
+	if rate.Sign() <= 0 {
+		return Decimal{}, reader.errorAt(column, fmt.Errorf("exchange rate %s is not positive", rate))
+	}
+	return rate, nil
+}
+
+func (reader *csvTableReader) readLong() (ExchangeRatesTable, error) {
+	table := make(ExchangeRatesTable)
+	first := true
+	for {
+		record, err := reader.csv.Read()
+		if err == io.EOF {
+			return table, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if first {
+			first = false
+			if strings.EqualFold(strings.TrimSpace(record[0]), "from") {
+				continue
+			}
+		}
+		fromCurrencyIsoCode, err := reader.currency(record, 1)
+		if err != nil {
+			return nil, err
+		}
+		toCurrencyIsoCode, err := reader.currency(record, 2)
+		if err != nil {
+			return nil, err
+		}
+		if fromCurrencyIsoCode == toCurrencyIsoCode {
+			return nil, reader.errorAt(2, fmt.Errorf("exchange rate of %s to itself", fromCurrencyIsoCode))
+		}
+		rate, err := reader.rate(record, 3)
+		if err != nil {
+			return nil, err
+		}
+		if table[fromCurrencyIsoCode] == nil {
+			table[fromCurrencyIsoCode] = make(ExchangeRates)
+		}
+		if _, found := table[fromCurrencyIsoCode][toCurrencyIsoCode]; found {
+			return nil, reader.errorAt(3, fmt.Errorf("duplicate exchange rate from %s to %s", fromCurrencyIsoCode, toCurrencyIsoCode))
+		}
+		table[fromCurrencyIsoCode][toCurrencyIsoCode] = rate
+	}
+}
+
+func (reader *csvTableReader) readMatrix() (ExchangeRatesTable, error) {
+	header, err := reader.csv.Read()
+	if err == io.EOF {
+		return make(ExchangeRatesTable), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	toCurrencies := make([]string, len(header))
+	for column := 2; column <= len(header); column++ {
+		isoCode, err := reader.currency(header, column)
+		if err != nil {
+			return nil, err
+		}
+		for _, previous := range toCurrencies {
+			if previous == isoCode {
+				return nil, reader.errorAt(column, fmt.Errorf("duplicate currency %s", isoCode))
+			}
+		}
+		toCurrencies[column-1] = isoCode
+	}
+
+	table := make(ExchangeRatesTable)
+	for {
+		record, err := reader.csv.Read()
+		if err == io.EOF {
+			return table, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		fromCurrencyIsoCode, err := reader.currency(record, 1)
+		if err != nil {
+			return nil, err
+		}
+		if _, found := table[fromCurrencyIsoCode]; found {
+			return nil, reader.errorAt(1, fmt.Errorf("duplicate currency %s", fromCurrencyIsoCode))
+		}
+		table[fromCurrencyIsoCode] = make(ExchangeRates)
+		for column := 2; column <= len(record); column++ {
+			toCurrencyIsoCode := toCurrencies[column-1]
+			if toCurrencyIsoCode == fromCurrencyIsoCode || strings.TrimSpace(record[column-1]) == "" {
+				continue
+			}
+			rate, err := reader.rate(record, column)
+			if err != nil {
+				return nil, err
+			}
+			table[fromCurrencyIsoCode][toCurrencyIsoCode] = rate
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package money_test
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/pioz/money"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExchangeRatesTableWriteCSV(t *testing.T) {
+	table := money.ExchangeRatesTable{
+		"USD": {"EUR": money.MustParseDecimal("0.87"), "GBP": money.MustParseDecimal("0.74")},
+		"EUR": {"USD": money.MustParseDecimal("1.16"), "JPY": money.MustParseDecimal("7/6")},
+	}
+
+	var buf bytes.Buffer
+	err := table.WriteCSV(&buf, money.CSVLong)
+	assert.Nil(t, err)
+	assert.Equal(t, "from,to,rate\nEUR,JPY,7/6\nEUR,USD,1.16\nUSD,EUR,0.87\nUSD,GBP,0.74\n", buf.String())
+	reloaded, err := money.ReadExchangeRatesTableCSV(&buf, money.CSVLong, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, table, reloaded)
+
+	buf.Reset()
+	err = table.WriteCSV(&buf, money.CSVMatrix)
+	assert.Nil(t, err)
+	assert.Equal(t, ",EUR,GBP,JPY,USD\nEUR,,,7/6,1.16\nUSD,0.87,0.74,,\n", buf.String())
+	reloaded, err = money.ReadExchangeRatesTableCSV(&buf, money.CSVMatrix, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, table, reloaded)
+
+	err = table.WriteCSV(&buf, money.CSVFormat(9))
+	assert.EqualError(t, err, "unknown CSV format 9")
+}
+
+func TestReadExchangeRatesTableCSVLong(t *testing.T) {
+	// The header is optional
+	table, err := money.ReadExchangeRatesTableCSV(strings.NewReader("EUR, USD, 1.16\nUSD,EUR,0.87\n"), money.CSVLong, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, money.ExchangeRatesTableFromFloat(map[string]map[string]float64{"EUR": {"USD": 1.16}, "USD": {"EUR": 0.87}}), table)
+
+	tests := []struct {
+		csv string
+		err string
+	}{
+		{"from,to,rate\nEUR,XXX,1.16\n", "line 2, column 2: bank does not support XXX currency"},
+		{"from,to,rate\nEUR,USD,abc\n", `line 2, column 3: invalid decimal "abc"`},
+		{"from,to,rate\nEUR,USD,0\n", "line 2, column 3: exchange rate 0 is not positive"},
+		{"from,to,rate\nEUR,EUR,1\n", "line 2, column 2: exchange rate of EUR to itself"},
+		{"from,to,rate\nEUR,USD,1.16\n\"EUR\",USD,1.2\n", "line 3, column 3: duplicate exchange rate from EUR to USD"},
+		{"from,to,rate\nEUR,USD\n", "record on line 2: wrong number of fields"},
+	}
+	for _, test := range tests {
+		_, err = money.ReadExchangeRatesTableCSV(strings.NewReader(test.csv), money.CSVLong, nil)
+		assert.EqualError(t, err, test.err)
+	}
+
+	_, err = money.ReadExchangeRatesTableCSV(strings.NewReader("EUR,JPY,130\n"), money.CSVLong, []money.Currency{money.EUR, money.USD})
+	assert.True(t, errors.Is(err, money.ErrUnsupportedCurrency))
+	var csvErr *money.CSVError
+	assert.True(t, errors.As(err, &csvErr))
+	assert.Equal(t, 1, csvErr.Line)
+	assert.Equal(t, 2, csvErr.Column)
+
+	_, err = money.ReadExchangeRatesTableCSV(strings.NewReader("EUR,USD\n"), money.CSVLong, nil)
+	var parseErr *csv.ParseError
+	assert.True(t, errors.As(err, &parseErr))
+}
+
+func TestReadExchangeRatesTableCSVMatrix(t *testing.T) {
+	table, err := money.ReadExchangeRatesTableCSV(strings.NewReader("from/to,EUR,USD,JPY\nEUR,1,1.16,130\nUSD,0.87,,\n"), money.CSVMatrix, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, money.ExchangeRatesTableFromFloat(map[string]map[string]float64{"EUR": {"USD": 1.16, "JPY": 130}, "USD": {"EUR": 0.87}}), table)
+
+	table, err = money.ReadExchangeRatesTableCSV(strings.NewReader(""), money.CSVMatrix, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, money.ExchangeRatesTable{}, table)
+
+	tests := []struct {
+		csv string
+		err string
+	}{
+		{",EUR,XXX\n", "line 1, column 3: bank does not support XXX currency"},
+		{",EUR,USD,EUR\n", "line 1, column 4: duplicate currency EUR"},
+		{",EUR,USD\nEUR,,1.16\nXXX,1,\n", "line 3, column 1: bank does not support XXX currency"},
+		{",EUR,USD\nEUR,,1.16\nEUR,,1.2\n", "line 3, column 1: duplicate currency EUR"},
+		{",EUR,USD\nEUR,,-1.16\n", "line 2, column 3: exchange rate -1.16 is not positive"},
+		{",EUR,USD\nEUR,,\"1,16\"\n", `line 2, column 3: invalid decimal "1,16"`},
+		{",EUR,USD\nEUR,1.16\n", "record on line 2: wrong number of fields"},
+	}
+	for _, test := range tests {
+		_, err = money.ReadExchangeRatesTableCSV(strings.NewReader(test.csv), money.CSVMatrix, nil)
+		assert.EqualError(t, err, test.err)
+	}
+
+	_, err = money.ReadExchangeRatesTableCSV(strings.NewReader(""), money.CSVFormat(9), nil)
+	assert.EqualError(t, err, "unknown CSV format 9")
+}
@@ -1,42 +1,119 @@
 package money
 
 import (
-	"io"
+	"fmt"
 	"os"
+	"path/filepath"
+	"strconv"
+	"time"
 )
 
-// ExchangeRatesTableFileCache implements the ExchangeRatesTableCache
-// interface to cache an exchange rates table into a file.
+// ExchangeRatesTableFileCache implements the ExchangeRatesTableEnvelopeCache
+// interface to cache an exchange rates table and its metadata into a file.
+//
+// Write replaces the file atomically: the table is written and synced to a
+// temporary file in the same directory, that is then renamed to FilePath. So
+// Read always gets a whole table, even if a write fails midway. Writes are
+// serialized with an exclusive lock on the file FilePath + ".lock", so several
+// processes can share the same cache file. The lock is not supported on
+// Windows and on some other systems, where concurrent writes are still atomic
+// but not serialized.
 type ExchangeRatesTableFileCache struct {
 	// File path where to store the cache.
 	FilePath string
+	// Encoding of the file, nil means GobCacheEncoding. Changing the encoding
+	// makes the existing file unreadable until it is written again.
+	Encoding CacheEncoding
 }
 
 // Read implements the Read method of ExchangeRatesTableCache interface.
 func (c ExchangeRatesTableFileCache) Read() (ExchangeRatesTable, error) {
-	var table ExchangeRatesTable
-	f, err := os.Open(c.FilePath)
+	envelope, err := c.ReadEnvelope()
 	if err != nil {
 		return nil, err
 	}
-	defer f.Close()
-	_, err = io.Copy(&table, f)
+	return envelope.Table, nil
+}
+
+// Write implements the Write method of ExchangeRatesTableCache interface. The
+// fetch time of the table is set to now.
+func (c ExchangeRatesTableFileCache) Write(table ExchangeRatesTable) error {
+	return c.WriteEnvelope(&CacheEnvelope{FetchedAt: time.Now(), Table: table})
+}
+
+// ReadEnvelope implements the ReadEnvelope method of
+// ExchangeRatesTableEnvelopeCache interface.
+func (c ExchangeRatesTableFileCache) ReadEnvelope() (*CacheEnvelope, error) {
+	f, err := os.Open(c.FilePath)
 	if err != nil {
 		return nil, err
 	}
-	return table, nil
+	defer f.Close()
+	return c.encoding().Decode(f)
 }
 
-// Write implements the Write method of ExchangeRatesTableCache interface.
-func (c ExchangeRatesTableFileCache) Write(table ExchangeRatesTable) error {
-	f, err := os.OpenFile(c.FilePath, os.O_WRONLY|os.O_CREATE, 0644)
+// WriteEnvelope implements the WriteEnvelope method of
+// ExchangeRatesTableEnvelopeCache interface. The version of the envelope is
+// set to CacheFormatVersion.
+func (c ExchangeRatesTableFileCache) WriteEnvelope(envelope *CacheEnvelope) error {
+	lock, err := lockFile(c.FilePath + ".lock")
+	if err != nil {
+		return fmt.Errorf("lock cache file %s: %w", c.FilePath, err)
+	}
+	defer unlockFile(lock)
+
+	dir, name := filepath.Split(c.FilePath)
+	f, err := createTempFile(dir, name)
 	if err != nil {
 		return err
 	}
-	defer f.Close()
-	_, err = io.Copy(f, &table)
+	// Has no effect after the rename
+	defer os.Remove(f.Name())
+	versioned := *envelope
+	versioned.Version = CacheFormatVersion
+	err = c.encoding().Encode(f, &versioned)
+	if err == nil {
+		err = f.Sync()
+	}
+	closeErr := f.Close()
+	if err == nil {
+		err = closeErr
+	}
+	// The mode of an existing file is kept, otherwise the temporary file has
+	// been created with the default mode
+	if info, statErr := os.Stat(c.FilePath); err == nil && statErr == nil {
+		err = os.Chmod(f.Name(), info.Mode().Perm())
+	}
 	if err != nil {
 		return err
 	}
+	err = os.Rename(f.Name(), c.FilePath)
+	if err != nil {
+		return err
+	}
+	syncDir(dir)
 	return nil
 }
+
+// Private functions
+
+// createTempFile creates a new file in dir with a name that starts with name.
+// Unlike os.CreateTemp, the file is created with mode 0666 (before umask),
+// like os.Create.
+func createTempFile(dir, name string) (*os.File, error) {
+	for i := 0; ; i++ {
+		path := filepath.Join(dir, name+".tmp-"+strconv.FormatInt(time.Now().UnixNano()+int64(i), 36))
+		f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0666)
+		if os.IsExist(err) && i < 10000 {
+			continue
+		}
+		return f, err
+	}
+}
+
+func (c ExchangeRatesTableFileCache) encoding() CacheEncoding {
+	if c.Encoding == nil {
+		return GobCacheEncoding{}
+	}
+	return c.Encoding
+}
@@ -1,7 +1,12 @@
 package money_test
 
 import (
+	"bytes"
+	"encoding/gob"
+	"errors"
 	"os"
+	"path/filepath"
+	"sync"
 	"testing"
 
 	"github.com/pioz/money"
@@ -9,15 +14,16 @@ import (
 )
 
 func TestWrite(t *testing.T) {
-	table := money.ExchangeRatesTable{
+	table := money.ExchangeRatesTableFromFloat(map[string]map[string]float64{
 		"EUR": {"USD": 1.2},
 		"USD": {"EUR": 0.8},
-	}
+	})
 
 	brokenFileCache := money.ExchangeRatesTableFileCache{FilePath: "/tmp/not-found/not-found"}
 	err := brokenFileCache.Write(table)
 	assert.NotNil(t, err)
-	assert.Equal(t, "open /tmp/not-found/not-found: no such file or directory", err.Error())
+	assert.True(t, errors.Is(err, os.ErrNotExist))
+	assert.Equal(t, "lock cache file /tmp/not-found/not-found: open /tmp/not-found/not-found.lock: no such file or directory", err.Error())
 
 	_, err = brokenFileCache.Read()
 	assert.NotNil(t, err)
@@ -32,6 +38,97 @@ func TestWrite(t *testing.T) {
 	reloadedRates, err := fileCache.Read()
 	assert.Nil(t, err)
 
-	assert.Equal(t, 1.2, reloadedRates["EUR"]["USD"])
-	assert.Equal(t, 0.8, reloadedRates["USD"]["EUR"])
+	assert.Equal(t, "1.2", reloadedRates["EUR"]["USD"].String())
+	assert.Equal(t, "0.8", reloadedRates["USD"]["EUR"].String())
+}
+
+func TestReadFloatCache(t *testing.T) {
+	fileCache := money.ExchangeRatesTableFileCache{FilePath: "/tmp/go-money-float-exchange-rates-table-cache"}
+	defer os.RemoveAll(fileCache.FilePath)
+
+	f, err := os.Create(fileCache.FilePath)
+	assert.Nil(t, err)
+	err = gob.NewEncoder(f).Encode(map[string]map[string]float64{"EUR": {"USD": 1.2}})
+	assert.Nil(t, err)
+	f.Close()
+
+	table, err := fileCache.Read()
+	assert.Nil(t, err)
+	assert.Equal(t, "1.2", table["EUR"]["USD"].String())
+}
+
+func TestWriteReplacesFile(t *testing.T) {
+	dir := t.TempDir()
+	fileCache := money.ExchangeRatesTableFileCache{FilePath: filepath.Join(dir, "cache")}
+
+	err := fileCache.Write(allCurrenciesExchangeRatesTable())
+	assert.Nil(t, err)
+	small := money.ExchangeRatesTableFromFloat(map[string]map[string]float64{"EUR": {"USD": 1.2}})
+	err = fileCache.Write(small)
+	assert.Nil(t, err)
+
+	// No trailing bytes of the previous table
+	content, err := os.ReadFile(fileCache.FilePath)
+	assert.Nil(t, err)
+	r := bytes.NewReader(content)
+	var envelope money.CacheEnvelope
+	err = gob.NewDecoder(r).Decode(&envelope)
+	assert.Nil(t, err)
+	assert.Equal(t, small, envelope.Table)
+	assert.Equal(t, 0, r.Len())
+
+	// The file has the default mode, and then its mode is kept
+	reference, err := os.Create(filepath.Join(dir, "reference"))
+	assert.Nil(t, err)
+	reference.Close()
+	defaultInfo, err := os.Stat(reference.Name())
+	assert.Nil(t, err)
+	os.Remove(reference.Name())
+	info, err := os.Stat(fileCache.FilePath)
+	assert.Nil(t, err)
+	assert.Equal(t, defaultInfo.Mode().Perm(), info.Mode().Perm())
+	err = os.Chmod(fileCache.FilePath, 0600)
+	assert.Nil(t, err)
+	err = fileCache.Write(small)
+	assert.Nil(t, err)
+	info, err = os.Stat(fileCache.FilePath)
+	assert.Nil(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	// No temporary files are left
+	entries, err := os.ReadDir(dir)
+	assert.Nil(t, err)
+	names := make([]string, 0)
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	assert.Equal(t, []string{"cache", "cache.lock"}, names)
+}
+
+func TestWriteConcurrently(t *testing.T) {
+	fileCache := money.ExchangeRatesTableFileCache{FilePath: filepath.Join(t.TempDir(), "cache")}
+	large := allCurrenciesExchangeRatesTable()
+	small := money.ExchangeRatesTableFromFloat(map[string]map[string]float64{"EUR": {"USD": 1.2}})
+	err := fileCache.Write(small)
+	assert.Nil(t, err)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		table := small
+		if i%2 == 0 {
+			table = large
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.Nil(t, fileCache.Write(table))
+		}()
+	}
+	// Readers always get a whole table
+	for i := 0; i < 20; i++ {
+		table, err := fileCache.Read()
+		assert.Nil(t, err)
+		assert.True(t, len(table) == len(small) || len(table) == len(large))
+	}
+	wg.Wait()
 }
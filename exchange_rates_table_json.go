@@ -0,0 +1,124 @@
+package money
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// exchangeRatesTableDocument is the JSON encoding of an exchange rates table
+// with metadata.
+type exchangeRatesTableDocument struct {
+	Metadata map[string]string  `json:"metadata,omitempty"`
+	Rates    ExchangeRatesTable `json:"rates"`
+}
+
+// MarshalCanonicalJSON returns the canonical JSON encoding of the table: the
+// currencies are sorted by ISO code, there are no spaces, and the exchange
+// rates are encoded as by Decimal.MarshalJSON. So equal tables are always
+// encoded in the same bytes, which can be compared or hashed.
+//
+// If metadata is not empty, the table is wrapped in an object with the
+// metadata, where the keys are sorted too:
+//
+//	{"metadata":{"provider":"ecb"},"rates":{"EUR":{"USD":1.16}}}
+func (table ExchangeRatesTable) MarshalCanonicalJSON(metadata map[string]string) ([]byte, error) {
+	var v interface{} = table
+	if len(metadata) > 0 {
+		v = exchangeRatesTableDocument{Metadata: metadata, Rates: table}
+	}
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	err := encoder.Encode(v)
+	if err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+// ExchangeRatesTableFromJSON parses data, the JSON encoding of an exchange
+// rates table, optionally wrapped with metadata as written by
+// MarshalCanonicalJSON. Returns the table and the metadata, nil if data has no
+// metadata. It can be used to load a table embedded in the program:
+//
+//	//go:embed rates.json
+//	var ratesJSON []byte
+//
+//	table, _, err := money.ExchangeRatesTableFromJSON(ratesJSON)
+//	bank, err := money.NewBankFromStaticExchangeRatesTable(money.AllCurrencies, table)
+func ExchangeRatesTableFromJSON(data []byte) (ExchangeRatesTable, map[string]string, error) {
+	var fields map[string]json.RawMessage
+	err := json.Unmarshal(data, &fields)
+	if err != nil {
+		return nil, nil, err
+	}
+	if isExchangeRatesTableDocument(fields) {
+		var document exchangeRatesTableDocument
+		err = json.Unmarshal(data, &document)
+		if err != nil {
+			return nil, nil, err
+		}
+		if document.Rates == nil {
+			document.Rates = make(ExchangeRatesTable)
+		}
+		return document.Rates, document.Metadata, nil
+	}
+	var table ExchangeRatesTable
+	err = json.Unmarshal(data, &table)
+	if err != nil {
+		return nil, nil, err
+	}
+	return table, nil, nil
+}
+
+// Scan scan value into the table, implements sql.Scanner interface. value can
+// be a []byte or a string with the JSON encoding of the table, also wrapped
+// with metadata, that are discarded (see ExchangeRatesTableFromJSON), or nil,
+// that is scanned as nil table (see Value).
+func (table *ExchangeRatesTable) Scan(value interface{}) error {
+	var data []byte
+	switch v := value.(type) {
+	case nil:
+		*table = nil
+		return nil
+	case string:
+		data = []byte(v)
+	case []byte:
+		data = v
+	default:
+		return errors.New(fmt.Sprint("Failed to unmarshal ExchangeRatesTable JSON value:", value))
+	}
+	decoded, _, err := ExchangeRatesTableFromJSON(data)
+	if err != nil {
+		return err
+	}
+	*table = decoded
+	return nil
+}
+
+// Value return the canonical JSON encoding of the table (see
+// MarshalCanonicalJSON), implement driver.Valuer interface. An empty table is
+// nil.
+func (table ExchangeRatesTable) Value() (driver.Value, error) {
+	if len(table) == 0 {
+		return nil, nil
+	}
+	return table.MarshalCanonicalJSON(nil)
+}
+
+// Private functions
+
+func isExchangeRatesTableDocument(fields map[string]json.RawMessage) bool {
+	if _, found := fields["rates"]; !found {
+		return false
+	}
+	for key := range fields {
+		if key != "rates" && key != "metadata" {
+			return false
+		}
+	}
+	return true
+}
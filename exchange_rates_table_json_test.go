@@ -0,0 +1,74 @@
+package money_test
+
+import (
+	_ "embed"
+	"testing"
+
+	"github.com/pioz/money"
+	"github.com/stretchr/testify/assert"
+)
+
+//go:embed testdata/exchange_rates_table.json
+var exchangeRatesTableJSON []byte
+
+func TestMarshalCanonicalJSON(t *testing.T) {
+	table := money.ExchangeRatesTable{
+		"USD": {"GBP": money.MustParseDecimal("0.74"), "EUR": money.MustParseDecimal("0.87")},
+		"EUR": {"USD": money.MustParseDecimal("1.16"), "JPY": money.MustParseDecimal("7/6")},
+	}
+	data, err := table.MarshalCanonicalJSON(nil)
+	assert.Nil(t, err)
+	assert.Equal(t, `{"EUR":{"JPY":"7/6","USD":1.16},"USD":{"EUR":0.87,"GBP":0.74}}`, string(data))
+	reloaded, metadata, err := money.ExchangeRatesTableFromJSON(data)
+	assert.Nil(t, err)
+	assert.Nil(t, metadata)
+	assert.Equal(t, table, reloaded)
+
+	data, err = table.MarshalCanonicalJSON(map[string]string{"provider": "ecb", "date": "2026-03-02"})
+	assert.Nil(t, err)
+	assert.Equal(t, `{"metadata":{"date":"2026-03-02","provider":"ecb"},"rates":{"EUR":{"JPY":"7/6","USD":1.16},"USD":{"EUR":0.87,"GBP":0.74}}}`, string(data))
+	reloaded, metadata, err = money.ExchangeRatesTableFromJSON(data)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{"provider": "ecb", "date": "2026-03-02"}, metadata)
+	assert.Equal(t, table, reloaded)
+
+	_, _, err = money.ExchangeRatesTableFromJSON([]byte(`{"EUR":{"USD":"abc"}}`))
+	assert.NotNil(t, err)
+	_, _, err = money.ExchangeRatesTableFromJSON([]byte(`[]`))
+	assert.NotNil(t, err)
+}
+
+func TestExchangeRatesTableScanValue(t *testing.T) {
+	table := money.ExchangeRatesTableFromFloat(map[string]map[string]float64{"USD": {"EUR": 0.87}, "EUR": {"USD": 1.16}})
+	value, err := table.Value()
+	assert.Nil(t, err)
+	assert.Equal(t, []byte(`{"EUR":{"USD":1.16},"USD":{"EUR":0.87}}`), value)
+
+	var scanned money.ExchangeRatesTable
+	err = scanned.Scan(value)
+	assert.Nil(t, err)
+	assert.Equal(t, table, scanned)
+	err = scanned.Scan(string(exchangeRatesTableJSON))
+	assert.Nil(t, err)
+	assert.Equal(t, "130", scanned["EUR"]["JPY"].String())
+	err = scanned.Scan(nil)
+	assert.Nil(t, err)
+	assert.Nil(t, scanned)
+	err = scanned.Scan(42)
+	assert.EqualError(t, err, "Failed to unmarshal ExchangeRatesTable JSON value:42")
+
+	value, err = money.ExchangeRatesTable{}.Value()
+	assert.Nil(t, err)
+	assert.Nil(t, value)
+}
+
+func TestNewBankFromEmbeddedExchangeRatesTable(t *testing.T) {
+	table, metadata, err := money.ExchangeRatesTableFromJSON(exchangeRatesTableJSON)
+	assert.Nil(t, err)
+	assert.Equal(t, "ecb", metadata["provider"])
+	bank, err := money.NewBankFromStaticExchangeRatesTable([]money.Currency{money.EUR, money.USD, money.JPY}, table)
+	assert.Nil(t, err)
+	rate, err := bank.GetExchangeRate("EUR", "JPY")
+	assert.Nil(t, err)
+	assert.Equal(t, 130.0, rate)
+}
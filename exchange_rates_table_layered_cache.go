@@ -0,0 +1,90 @@
+package money
+
+import "fmt"
+
+// ExchangeRatesTableLayeredCache implements the
+// ExchangeRatesTableEnvelopeCache interface combining several caches, for
+// example a memory cache backed by a file cache backed by a SQL cache.
+//
+// Read returns the table of the first layer that succeeds, and writes it to
+// the previous layers, that failed, so that the next reads are served by the
+// fastest layer. A table without fetch time, read from a layer that does not
+// implement the ExchangeRatesTableEnvelopeCache interface, is not written to
+// the previous layers, because it would never expire there (see
+// Bank.SetCacheMaxAge). Write writes the table to all layers. The metadata of
+// the table are kept only by the layers that implement the
+// ExchangeRatesTableEnvelopeCache interface.
+type ExchangeRatesTableLayeredCache struct {
+	// Layers of the cache, from the first to read to the last.
+	Layers []ExchangeRatesTableCache
+}
+
+// NewExchangeRatesTableLayeredCache returns a layered cache with the given
+// layers.
+func NewExchangeRatesTableLayeredCache(layers ...ExchangeRatesTableCache) ExchangeRatesTableLayeredCache {
+	return ExchangeRatesTableLayeredCache{Layers: layers}
+}
+
+// Read implements the Read method of ExchangeRatesTableCache interface.
+func (c ExchangeRatesTableLayeredCache) Read() (ExchangeRatesTable, error) {
+	envelope, err := c.ReadEnvelope()
+	if err != nil {
+		return nil, err
+	}
+	return envelope.Table, nil
+}
+
+// Write implements the Write method of ExchangeRatesTableCache interface. The
+// table is written to all layers with Write, so each layer sets the metadata
+// as it does when used alone.
+func (c ExchangeRatesTableLayeredCache) Write(table ExchangeRatesTable) error {
+	var firstErr error
+	for i, layer := range c.Layers {
+		err := layer.Write(table)
+		if err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("cache layer %d: %w", i, err)
+		}
+	}
+	return firstErr
+}
+
+// ReadEnvelope implements the ReadEnvelope method of
+// ExchangeRatesTableEnvelopeCache interface. If all layers fail, returns the
+// error of the last layer.
+func (c ExchangeRatesTableLayeredCache) ReadEnvelope() (*CacheEnvelope, error) {
+	if len(c.Layers) == 0 {
+		return nil, ErrEmptyCache
+	}
+	var err error
+	for i, layer := range c.Layers {
+		var envelope *CacheEnvelope
+		envelope, err = readCacheEnvelope(layer)
+		if err != nil {
+			err = fmt.Errorf("cache layer %d: %w", i, err)
+			continue
+		}
+		if envelope.FetchedAt.IsZero() {
+			return envelope, nil
+		}
+		// Errors writing a faster layer are ignored, the table has been read
+		for _, previous := range c.Layers[:i] {
+			_ = writeCacheEnvelope(previous, envelope)
+		}
+		return envelope, nil
+	}
+	return nil, err
+}
+
+// WriteEnvelope implements the WriteEnvelope method of
+// ExchangeRatesTableEnvelopeCache interface. The envelope is written to all
+// layers, also when a layer fails, and the first error is returned.
+func (c ExchangeRatesTableLayeredCache) WriteEnvelope(envelope *CacheEnvelope) error {
+	var firstErr error
+	for i, layer := range c.Layers {
+		err := writeCacheEnvelope(layer, envelope)
+		if err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("cache layer %d: %w", i, err)
+		}
+	}
+	return firstErr
+}
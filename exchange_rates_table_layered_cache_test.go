@@ -0,0 +1,123 @@
+package money_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pioz/money"
+	"github.com/stretchr/testify/assert"
+)
+
+type brokenCache struct{}
+
+func (brokenCache) Read() (money.ExchangeRatesTable, error) {
+	return nil, os.ErrPermission
+}
+
+func (brokenCache) Write(table money.ExchangeRatesTable) error {
+	return os.ErrPermission
+}
+
+// plainCache implements only the ExchangeRatesTableCache interface, so its
+// table has no fetch time.
+type plainCache struct {
+	table money.ExchangeRatesTable
+}
+
+func (c *plainCache) Read() (money.ExchangeRatesTable, error) {
+	return c.table, nil
+}
+
+func (c *plainCache) Write(table money.ExchangeRatesTable) error {
+	c.table = table
+	return nil
+}
+
+func TestLayeredCacheRead(t *testing.T) {
+	memoryCache := &money.ExchangeRatesTableMemoryCache{}
+	fileCache := money.ExchangeRatesTableFileCache{FilePath: filepath.Join(t.TempDir(), "cache")}
+	cache := money.NewExchangeRatesTableLayeredCache(memoryCache, fileCache)
+
+	_, err := cache.Read()
+	assert.True(t, errors.Is(err, os.ErrNotExist))
+	assert.Equal(t, "cache layer 1: open "+fileCache.FilePath+": no such file or directory", err.Error())
+
+	// The table is read from the first layer that succeeds
+	table := money.ExchangeRatesTableFromFloat(map[string]map[string]float64{"EUR": {"USD": 1.2}})
+	fetchedAt := time.Date(2026, 3, 2, 10, 30, 0, 0, time.UTC)
+	err = fileCache.WriteEnvelope(&money.CacheEnvelope{FetchedAt: fetchedAt, Provider: "test", Table: table})
+	assert.Nil(t, err)
+	envelope, err := cache.ReadEnvelope()
+	assert.Nil(t, err)
+	assert.Equal(t, table, envelope.Table)
+	assert.Equal(t, "test", envelope.Provider)
+
+	// and it is written to the previous layers
+	envelope, err = memoryCache.ReadEnvelope()
+	assert.Nil(t, err)
+	assert.Equal(t, table, envelope.Table)
+	assert.True(t, fetchedAt.Equal(envelope.FetchedAt))
+
+	err = fileCache.Write(money.ExchangeRatesTableFromFloat(map[string]map[string]float64{"EUR": {"USD": 1.3}}))
+	assert.Nil(t, err)
+	reloaded, err := cache.Read()
+	assert.Nil(t, err)
+	assert.Equal(t, "1.2", reloaded["EUR"]["USD"].String())
+
+	_, err = money.NewExchangeRatesTableLayeredCache().Read()
+	assert.True(t, errors.Is(err, money.ErrEmptyCache))
+
+	// A table without fetch time is not written to the previous layers
+	memoryCache = &money.ExchangeRatesTableMemoryCache{}
+	cache = money.NewExchangeRatesTableLayeredCache(memoryCache, &plainCache{table: table})
+	envelope, err = cache.ReadEnvelope()
+	assert.Nil(t, err)
+	assert.Equal(t, table, envelope.Table)
+	assert.True(t, envelope.FetchedAt.IsZero())
+	_, err = memoryCache.ReadEnvelope()
+	assert.NotNil(t, err)
+}
+
+func TestLayeredCacheWrite(t *testing.T) {
+	memoryCache := &money.ExchangeRatesTableMemoryCache{}
+	fileCache := money.ExchangeRatesTableFileCache{FilePath: filepath.Join(t.TempDir(), "cache")}
+	cache := money.NewExchangeRatesTableLayeredCache(memoryCache, brokenCache{}, fileCache)
+
+	// All layers are written, also if a layer fails
+	table := money.ExchangeRatesTableFromFloat(map[string]map[string]float64{"EUR": {"USD": 1.2}})
+	err := cache.Write(table)
+	assert.True(t, errors.Is(err, os.ErrPermission))
+	assert.Equal(t, "cache layer 1: permission denied", err.Error())
+	for _, layer := range []money.ExchangeRatesTableCache{memoryCache, fileCache} {
+		reloaded, err := layer.Read()
+		assert.Nil(t, err)
+		assert.Equal(t, table, reloaded)
+	}
+
+	// A broken layer is skipped when reading
+	memoryCache.Clear()
+	reloaded, err := cache.Read()
+	assert.Nil(t, err)
+	assert.Equal(t, table, reloaded)
+}
+
+func TestBankWithLayeredCache(t *testing.T) {
+	fileCache := money.ExchangeRatesTableFileCache{FilePath: filepath.Join(t.TempDir(), "cache")}
+	cache := money.NewExchangeRatesTableLayeredCache(&money.ExchangeRatesTableMemoryCache{}, fileCache)
+	_, err := money.NewBankWithOptions(
+		money.WithCurrencies([]money.Currency{money.EUR, money.USD}),
+		money.WithFetcher(func() (money.ExchangeRatesTable, error) {
+			return money.ExchangeRatesTableFromFloat(map[string]map[string]float64{"EUR": {"USD": 1.2}}), nil
+		}),
+		money.WithCache(cache),
+		money.WithProviderName("test"),
+	)
+	assert.Nil(t, err)
+	envelope, err := fileCache.ReadEnvelope()
+	assert.Nil(t, err)
+	assert.Equal(t, "test", envelope.Provider)
+	assert.Equal(t, "1.2", envelope.Table["EUR"]["USD"].String())
+}
@@ -0,0 +1,70 @@
+package money
+
+import (
+	"sync"
+	"time"
+)
+
+// ExchangeRatesTableMemoryCache implements the ExchangeRatesTableEnvelopeCache
+// interface to cache an exchange rates table and its metadata in memory. It is
+// safe for concurrent use and stores a copy of the table, so that the written
+// and the read tables can be modified without changing the cache. The zero
+// value is an empty cache, that must be used by pointer.
+type ExchangeRatesTableMemoryCache struct {
+	mu       sync.RWMutex
+	envelope *CacheEnvelope
+}
+
+// Read implements the Read method of ExchangeRatesTableCache interface.
+func (c *ExchangeRatesTableMemoryCache) Read() (ExchangeRatesTable, error) {
+	envelope, err := c.ReadEnvelope()
+	if err != nil {
+		return nil, err
+	}
+	return envelope.Table, nil
+}
+
+// Write implements the Write method of ExchangeRatesTableCache interface. The
+// fetch time of the table is set to now.
+func (c *ExchangeRatesTableMemoryCache) Write(table ExchangeRatesTable) error {
+	return c.WriteEnvelope(&CacheEnvelope{FetchedAt: time.Now(), Table: table})
+}
+
+// ReadEnvelope implements the ReadEnvelope method of
+// ExchangeRatesTableEnvelopeCache interface. Returns ErrEmptyCache if no table
+// has been written.
+func (c *ExchangeRatesTableMemoryCache) ReadEnvelope() (*CacheEnvelope, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.envelope == nil {
+		return nil, ErrEmptyCache
+	}
+	return c.envelope.clone(), nil
+}
+
+// WriteEnvelope implements the WriteEnvelope method of
+// ExchangeRatesTableEnvelopeCache interface. The version of the envelope is
+// set to CacheFormatVersion.
+func (c *ExchangeRatesTableMemoryCache) WriteEnvelope(envelope *CacheEnvelope) error {
+	envelope = envelope.clone()
+	envelope.Version = CacheFormatVersion
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.envelope = envelope
+	return nil
+}
+
+// Clear empties the cache.
+func (c *ExchangeRatesTableMemoryCache) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.envelope = nil
+}
+
+// Private functions
+
+func (envelope *CacheEnvelope) clone() *CacheEnvelope {
+	result := *envelope
+	result.Table = envelope.Table.clone()
+	return &result
+}
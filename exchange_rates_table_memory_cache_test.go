@@ -0,0 +1,49 @@
+package money_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/pioz/money"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryCache(t *testing.T) {
+	cache := &money.ExchangeRatesTableMemoryCache{}
+	_, err := cache.Read()
+	assert.True(t, errors.Is(err, money.ErrEmptyCache))
+
+	table := money.ExchangeRatesTableFromFloat(map[string]map[string]float64{"EUR": {"USD": 1.2}})
+	fetchedAt := time.Date(2026, 3, 2, 10, 30, 0, 0, time.UTC)
+	err = cache.WriteEnvelope(&money.CacheEnvelope{FetchedAt: fetchedAt, Provider: "test", Table: table})
+	assert.Nil(t, err)
+
+	// The cache stores a copy of the table
+	table["EUR"]["USD"] = money.NewDecimal(2, 1)
+	envelope, err := cache.ReadEnvelope()
+	assert.Nil(t, err)
+	assert.Equal(t, money.CacheFormatVersion, envelope.Version)
+	assert.Equal(t, fetchedAt, envelope.FetchedAt)
+	assert.Equal(t, "test", envelope.Provider)
+	assert.Equal(t, "1.2", envelope.Table["EUR"]["USD"].String())
+	envelope.Table["EUR"]["JPY"] = money.NewDecimal(130, 1)
+	reloaded, err := cache.Read()
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(reloaded["EUR"]))
+
+	cache.Clear()
+	_, err = cache.Read()
+	assert.True(t, errors.Is(err, money.ErrEmptyCache))
+}
+
+func TestBankWithMemoryCache(t *testing.T) {
+	cache := &money.ExchangeRatesTableMemoryCache{}
+	_, err := money.NewBank([]money.Currency{money.EUR, money.USD}, func() (money.ExchangeRatesTable, error) {
+		return money.ExchangeRatesTableFromFloat(map[string]map[string]float64{"EUR": {"USD": 1.2}}), nil
+	}, cache)
+	assert.Nil(t, err)
+	table, err := cache.Read()
+	assert.Nil(t, err)
+	assert.Equal(t, "1.2", table["EUR"]["USD"].String())
+}
@@ -0,0 +1,201 @@
+package money
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// DefaultSQLCacheTableName is the name of the database table used by
+// ExchangeRatesTableSQLCache when TableName is empty.
+const DefaultSQLCacheTableName = "exchange_rates_tables"
+
+// ExchangeRatesTableSQLCache implements the ExchangeRatesTableEnvelopeCache
+// interface to cache the exchange rates table in a database, so that several
+// instances of an application can share the same cache. Each write stores a
+// snapshot of the table, keyed by its fetch time, with a row for each currency
+// that holds the exchange rates from the currency encoded as JSON (see
+// ExchangeRates.Value). Read returns the last snapshot.
+//
+// The database table must exist, see CreateTable and Schema.
+type ExchangeRatesTableSQLCache struct {
+	DB *sql.DB
+	// Name of the database table, DefaultSQLCacheTableName if empty. It is
+	// used in the queries without escaping.
+	TableName string
+	// Number of snapshots to keep, the older ones are deleted at each write.
+	// Zero or less keeps all snapshots.
+	Keep int
+	// Use the numbered placeholders $1, $2... in the queries, as required by
+	// PostgreSQL, instead of ?.
+	NumberedPlaceholders bool
+}
+
+// Schema returns the statement that creates the database table, if it does
+// not exist. The fetch time is stored in Unix nanoseconds, so that it can be
+// compared in the same way by all databases.
+func (c ExchangeRatesTableSQLCache) Schema() string {
+	return fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+  fetched_at BIGINT NOT NULL,
+  provider VARCHAR(255) NOT NULL,
+  currency VARCHAR(3) NOT NULL,
+  rates TEXT,
+  PRIMARY KEY (fetched_at, currency)
+)`, c.tableName())
+}
+
+// CreateTable creates the database table, if it does not exist.
+func (c ExchangeRatesTableSQLCache) CreateTable() error {
+	_, err := c.DB.Exec(c.Schema())
+	return err
+}
+
+// Read implements the Read method of ExchangeRatesTableCache interface.
+func (c ExchangeRatesTableSQLCache) Read() (ExchangeRatesTable, error) {
+	envelope, err := c.ReadEnvelope()
+	if err != nil {
+		return nil, err
+	}
+	return envelope.Table, nil
+}
+
+// Write implements the Write method of ExchangeRatesTableCache interface. The
+// fetch time of the table is set to now.
+func (c ExchangeRatesTableSQLCache) Write(table ExchangeRatesTable) error {
+	return c.WriteEnvelope(&CacheEnvelope{FetchedAt: time.Now(), Table: table})
+}
+
+// ReadEnvelope implements the ReadEnvelope method of
+// ExchangeRatesTableEnvelopeCache interface. Returns sql.ErrNoRows if the
+// cache is empty.
+func (c ExchangeRatesTableSQLCache) ReadEnvelope() (*CacheEnvelope, error) {
+	rows, err := c.DB.Query(fmt.Sprintf(
+		"SELECT fetched_at, provider, currency, rates FROM %[1]s WHERE fetched_at = (SELECT MAX(fetched_at) FROM %[1]s)",
+		c.tableName(),
+	))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var envelope *CacheEnvelope
+	for rows.Next() {
+		var fetchedAt int64
+		var provider, currencyIsoCode string
+		var rates ExchangeRates
+		err = rows.Scan(&fetchedAt, &provider, &currencyIsoCode, &rates)
+		if err != nil {
+			return nil, err
+		}
+		if envelope == nil {
+			envelope = &CacheEnvelope{
+				Version:   CacheFormatVersion,
+				FetchedAt: time.Unix(0, fetchedAt),
+				Provider:  provider,
+				Table:     make(ExchangeRatesTable),
+			}
+		}
+		if rates == nil {
+			rates = make(ExchangeRates)
+		}
+		envelope.Table[currencyIsoCode] = rates
+	}
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+	if envelope == nil {
+		return nil, sql.ErrNoRows
+	}
+	return envelope, nil
+}
+
+// WriteEnvelope implements the WriteEnvelope method of
+// ExchangeRatesTableEnvelopeCache interface. It replaces the snapshot with
+// the same fetch time, if any, and then deletes the snapshots exceeding Keep.
+// A table without currencies has no rows, so it is not stored.
+func (c ExchangeRatesTableSQLCache) WriteEnvelope(envelope *CacheEnvelope) error {
+	tx, err := c.DB.Begin()
+	if err != nil {
+		return err
+	}
+	err = c.writeEnvelope(tx, envelope)
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// Private functions
+
+func (c ExchangeRatesTableSQLCache) tableName() string {
+	if c.TableName == "" {
+		return DefaultSQLCacheTableName
+	}
+	return c.TableName
+}
+
+// query replaces the ? placeholders of query with $1, $2... if
+// NumberedPlaceholders is true.
+func (c ExchangeRatesTableSQLCache) query(query string) string {
+	if !c.NumberedPlaceholders {
+		return query
+	}
+	var b strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			fmt.Fprintf(&b, "$%d", n)
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func (c ExchangeRatesTableSQLCache) writeEnvelope(tx *sql.Tx, envelope *CacheEnvelope) error {
+	fetchedAt := envelope.FetchedAt.UnixNano()
+	_, err := tx.Exec(c.query(fmt.Sprintf("DELETE FROM %s WHERE fetched_at = ?", c.tableName())), fetchedAt)
+	if err != nil {
+		return err
+	}
+	insert := c.query(fmt.Sprintf("INSERT INTO %s (fetched_at, provider, currency, rates) VALUES (?, ?, ?, ?)", c.tableName()))
+	for currencyIsoCode, rates := range envelope.Table {
+		_, err = tx.Exec(insert, fetchedAt, envelope.Provider, currencyIsoCode, rates)
+		if err != nil {
+			return err
+		}
+	}
+	if c.Keep <= 0 {
+		return nil
+	}
+
+	// The oldest snapshot to keep is found in Go, because not all databases
+	// support LIMIT in subqueries
+	rows, err := tx.Query(fmt.Sprintf("SELECT DISTINCT fetched_at FROM %s ORDER BY fetched_at DESC", c.tableName()))
+	if err != nil {
+		return err
+	}
+	var oldest int64
+	n := 0
+	for n < c.Keep && rows.Next() {
+		err = rows.Scan(&oldest)
+		if err != nil {
+			rows.Close()
+			return err
+		}
+		n++
+	}
+	err = rows.Close()
+	if err != nil {
+		return err
+	}
+	if n < c.Keep {
+		return nil
+	}
+	_, err = tx.Exec(c.query(fmt.Sprintf("DELETE FROM %s WHERE fetched_at < ?", c.tableName())), oldest)
+	return err
+}
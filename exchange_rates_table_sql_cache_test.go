@@ -0,0 +1,142 @@
+//go:build cgo
+// +build cgo
+
+// The SQLite driver requires cgo
+
+package money_test
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/pioz/money"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func openSQLiteCache(t *testing.T) money.ExchangeRatesTableSQLCache {
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "cache.db"))
+	require.Nil(t, err)
+	t.Cleanup(func() { db.Close() })
+	cache := money.ExchangeRatesTableSQLCache{DB: db}
+	require.Nil(t, cache.CreateTable())
+	// The schema can be created more times
+	require.Nil(t, cache.CreateTable())
+	return cache
+}
+
+func TestSQLCache(t *testing.T) {
+	cache := openSQLiteCache(t)
+	_, err := cache.Read()
+	assert.Equal(t, sql.ErrNoRows, err)
+
+	table := money.ExchangeRatesTableFromFloat(map[string]map[string]float64{
+		"EUR": {"USD": 1.2, "JPY": 130},
+		"USD": {"EUR": 0.8},
+		"JPY": {},
+	})
+	fetchedAt := time.Date(2026, 3, 2, 10, 30, 0, 123, time.UTC)
+	err = cache.WriteEnvelope(&money.CacheEnvelope{FetchedAt: fetchedAt, Provider: "test", Table: table})
+	assert.Nil(t, err)
+
+	envelope, err := cache.ReadEnvelope()
+	assert.Nil(t, err)
+	assert.Equal(t, money.CacheFormatVersion, envelope.Version)
+	assert.True(t, fetchedAt.Equal(envelope.FetchedAt))
+	assert.Equal(t, "test", envelope.Provider)
+	assert.Equal(t, table, envelope.Table)
+
+	// The last snapshot is read
+	newTable := money.ExchangeRatesTableFromFloat(map[string]map[string]float64{"EUR": {"USD": 1.3}})
+	err = cache.Write(newTable)
+	assert.Nil(t, err)
+	reloaded, err := cache.Read()
+	assert.Nil(t, err)
+	assert.Equal(t, newTable, reloaded)
+
+	// Write replaces the snapshot with the same fetch time
+	err = cache.WriteEnvelope(&money.CacheEnvelope{FetchedAt: fetchedAt, Provider: "other", Table: newTable})
+	assert.Nil(t, err)
+	var count int
+	err = cache.DB.QueryRow("SELECT COUNT(*) FROM exchange_rates_tables WHERE fetched_at = ?", fetchedAt.UnixNano()).Scan(&count)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, count)
+}
+
+func TestSQLCacheKeep(t *testing.T) {
+	cache := openSQLiteCache(t)
+	cache.Keep = 2
+	start := time.Now()
+	for i := 1; i <= 4; i++ {
+		err := cache.WriteEnvelope(&money.CacheEnvelope{
+			FetchedAt: start.Add(time.Duration(i) * time.Minute),
+			Table: money.ExchangeRatesTableFromFloat(map[string]map[string]float64{
+				"EUR": {"USD": float64(i)},
+				"USD": {"EUR": 1 / float64(i)},
+			}),
+		})
+		assert.Nil(t, err)
+	}
+
+	rows, err := cache.DB.Query("SELECT DISTINCT fetched_at FROM exchange_rates_tables ORDER BY fetched_at")
+	assert.Nil(t, err)
+	defer rows.Close()
+	var snapshots []int64
+	for rows.Next() {
+		var fetchedAt int64
+		assert.Nil(t, rows.Scan(&fetchedAt))
+		snapshots = append(snapshots, fetchedAt)
+	}
+	assert.Equal(t, []int64{start.Add(3 * time.Minute).UnixNano(), start.Add(4 * time.Minute).UnixNano()}, snapshots)
+
+	table, err := cache.Read()
+	assert.Nil(t, err)
+	assert.Equal(t, "4", table["EUR"]["USD"].String())
+}
+
+func TestSQLCacheSharedByBanks(t *testing.T) {
+	cache := openSQLiteCache(t)
+	cache.TableName = "rates"
+	assert.Nil(t, cache.CreateTable())
+
+	_, err := money.NewBankWithOptions(
+		money.WithCurrencies([]money.Currency{money.EUR, money.USD}),
+		money.WithFetcher(func() (money.ExchangeRatesTable, error) {
+			return money.ExchangeRatesTableFromFloat(map[string]map[string]float64{"EUR": {"USD": 1.2}}), nil
+		}),
+		money.WithCache(cache),
+		money.WithProviderName("test"),
+	)
+	assert.Nil(t, err)
+
+	release := make(chan struct{})
+	defer close(release)
+	bank, err := money.NewBankWithOptions(
+		money.WithCurrencies([]money.Currency{money.EUR, money.USD}),
+		money.WithFetcher(func() (money.ExchangeRatesTable, error) {
+			<-release
+			return nil, sql.ErrConnDone
+		}),
+		money.WithCache(cache),
+		money.WithLogger(nil),
+	)
+	assert.Nil(t, err)
+	rate, err := bank.GetExchangeRate("EUR", "USD")
+	assert.Nil(t, err)
+	assert.Equal(t, 1.2, rate)
+}
+
+func TestSQLCacheNumberedPlaceholders(t *testing.T) {
+	// SQLite supports also the numbered placeholders
+	cache := openSQLiteCache(t)
+	cache.NumberedPlaceholders = true
+	cache.Keep = 1
+	table := money.ExchangeRatesTableFromFloat(map[string]map[string]float64{"EUR": {"USD": 1.2}})
+	assert.Nil(t, cache.Write(table))
+	reloaded, err := cache.Read()
+	assert.Nil(t, err)
+	assert.Equal(t, table, reloaded)
+}
@@ -0,0 +1,139 @@
+package money_test
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/gob"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/pioz/money"
+	"github.com/stretchr/testify/assert"
+)
+
+func allCurrenciesExchangeRatesTable() money.ExchangeRatesTable {
+	table := make(money.ExchangeRatesTable)
+	for i, fromCurrency := range money.AllCurrencies {
+		table[fromCurrency.IsoCode] = make(money.ExchangeRates)
+		for j, toCurrency := range money.AllCurrencies {
+			if i != j {
+				table[fromCurrency.IsoCode][toCurrency.IsoCode] = money.NewDecimal(int64(j+1)*1000003, int64(i+1)*999983)
+			}
+		}
+	}
+	return table
+}
+
+func TestExchangeRatesTableWriteToReadFrom(t *testing.T) {
+	table := allCurrenciesExchangeRatesTable()
+	var buf bytes.Buffer
+	n, err := table.WriteTo(&buf)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(buf.Len()), n)
+	// Much bigger than the io.Copy buffer
+	assert.Greater(t, buf.Len(), 32*1024)
+
+	var decoded money.ExchangeRatesTable
+	n, err = decoded.ReadFrom(bytes.NewReader(buf.Bytes()))
+	assert.Nil(t, err)
+	assert.Equal(t, int64(buf.Len()), n)
+	assert.Equal(t, table, decoded)
+}
+
+func TestExchangeRatesTableReadFromConsumesOnlyTheEncoding(t *testing.T) {
+	first := money.ExchangeRatesTableFromFloat(map[string]map[string]float64{"EUR": {"USD": 1.2}})
+	second := money.ExchangeRatesTableFromFloat(map[string]map[string]float64{"USD": {"EUR": 0.8}})
+	var buf bytes.Buffer
+	n1, err := first.WriteTo(&buf)
+	assert.Nil(t, err)
+	err = gob.NewEncoder(&buf).Encode(map[string]map[string]float64{"GBP": {"EUR": 1.15}})
+	assert.Nil(t, err)
+	n2 := int64(buf.Len()) - n1
+	_, err = second.WriteTo(&buf)
+	assert.Nil(t, err)
+
+	r := bufio.NewReader(&buf)
+	var decoded money.ExchangeRatesTable
+	n, err := decoded.ReadFrom(r)
+	assert.Nil(t, err)
+	assert.Equal(t, n1, n)
+	assert.Equal(t, first, decoded)
+	n, err = decoded.ReadFrom(r)
+	assert.Nil(t, err)
+	assert.Equal(t, n2, n)
+	assert.Equal(t, "1.15", decoded["GBP"]["EUR"].String())
+	_, err = decoded.ReadFrom(r)
+	assert.Nil(t, err)
+	assert.Equal(t, second, decoded)
+}
+
+func TestExchangeRatesTableIoCopy(t *testing.T) {
+	table := allCurrenciesExchangeRatesTable()
+	f, err := os.CreateTemp("", "go-money-exchange-rates-table")
+	assert.Nil(t, err)
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	_, err = io.Copy(f, &table)
+	assert.Nil(t, err)
+	_, err = f.Seek(0, io.SeekStart)
+	assert.Nil(t, err)
+
+	var decoded money.ExchangeRatesTable
+	_, err = io.Copy(&decoded, f)
+	assert.Nil(t, err)
+	assert.Equal(t, table, decoded)
+}
+
+func TestExchangeRatesTableReadFromFloatTable(t *testing.T) {
+	var buf bytes.Buffer
+	err := gob.NewEncoder(&buf).Encode(map[string]map[string]float64{"EUR": {"USD": 1.2}})
+	assert.Nil(t, err)
+
+	var table money.ExchangeRatesTable
+	_, err = table.ReadFrom(&buf)
+	assert.Nil(t, err)
+	assert.Equal(t, "1.2", table["EUR"]["USD"].String())
+
+	// Named types, as encoded by the first versions of the package
+	type ExchangeRates map[string]float64
+	type ExchangeRatesTable map[string]ExchangeRates
+	buf.Reset()
+	err = gob.NewEncoder(&buf).Encode(ExchangeRatesTable{"USD": {"EUR": 0.8}})
+	assert.Nil(t, err)
+	_, err = table.ReadFrom(&buf)
+	assert.Nil(t, err)
+	assert.Equal(t, "0.8", table["USD"]["EUR"].String())
+
+	_, err = table.ReadFrom(bytes.NewReader([]byte("not a gob")))
+	assert.NotNil(t, err)
+	assert.Equal(t, "0.8", table["USD"]["EUR"].String())
+}
+
+func TestExchangeRatesTableReadShortBuffer(t *testing.T) {
+	table := allCurrenciesExchangeRatesTable()
+	_, err := table.Read(make([]byte, 32*1024))
+	assert.Equal(t, io.ErrShortBuffer, err)
+
+	b := make([]byte, 1024*1024)
+	n, err := table.Read(b)
+	assert.Equal(t, io.EOF, err)
+
+	var decoded money.ExchangeRatesTable
+	_, err = decoded.Write(b[:n])
+	assert.Nil(t, err)
+	assert.Equal(t, table, decoded)
+}
+
+func TestExchangeRatesTableFileCacheAllCurrencies(t *testing.T) {
+	table := allCurrenciesExchangeRatesTable()
+	fileCache := money.ExchangeRatesTableFileCache{FilePath: "/tmp/go-money-all-currencies-cache"}
+	defer os.RemoveAll(fileCache.FilePath)
+
+	err := fileCache.Write(table)
+	assert.Nil(t, err)
+	reloaded, err := fileCache.Read()
+	assert.Nil(t, err)
+	assert.Equal(t, table, reloaded)
+}
@@ -0,0 +1,18 @@
+//go:build !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd
+// +build !darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd
+
+package money
+
+import "os"
+
+// lockFile creates the file at path if needed. File locking is not supported
+// on this system, so the file is not locked.
+func lockFile(path string) (*os.File, error) {
+	return os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+}
+
+func unlockFile(f *os.File) {
+	f.Close()
+}
+
+func syncDir(dir string) {}
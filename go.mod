@@ -2,7 +2,10 @@ module github.com/pioz/money
 
 go 1.17
 
-require github.com/stretchr/testify v1.7.0
+require (
+	github.com/mattn/go-sqlite3 v1.14.17
+	github.com/stretchr/testify v1.7.0
+)
 
 require (
 	github.com/davecgh/go-spew v1.1.0 // indirect
@@ -0,0 +1,99 @@
+package money
+
+import "time"
+
+// SetHistoricalExchangeRatesTable stores table as the exchange rates table of
+// the bank at the given date. Only the day of date is taken into account, so
+// any time of the same day refers to the same table. A table already stored
+// for the same day is replaced. Exchange rates between currencies not
+// supported by the bank are ignored. It has no effect if the bank is a
+// snapshot.
+func (bank *Bank) SetHistoricalExchangeRatesTable(date time.Time, table ExchangeRatesTable) {
+	if bank.readOnly {
+		return
+	}
+	historicalTable := make(ExchangeRatesTable)
+	bank.eachSupportedExchangeRate(table, func(fromCurrencyIsoCode, toCurrencyIsoCode string, rate Decimal) {
+		if historicalTable[fromCurrencyIsoCode] == nil {
+			historicalTable[fromCurrencyIsoCode] = make(ExchangeRates)
+		}
+		historicalTable[fromCurrencyIsoCode][toCurrencyIsoCode] = rate
+	})
+	bank.mu.Lock()
+	defer bank.mu.Unlock()
+	bank.historicalExchangeRatesTables[dateKey(date)] = historicalTable
+}
+
+// LoadHistoricalExchangeRatesTables is a conveniently function to store a
+// whole historical series of exchange rates tables at once. It is the same of
+// calling SetHistoricalExchangeRatesTable for each date of series, so it has
+// no effect if the bank is a snapshot.
+func (bank *Bank) LoadHistoricalExchangeRatesTables(series map[time.Time]ExchangeRatesTable) {
+	for date, table := range series {
+		bank.SetHistoricalExchangeRatesTable(date, table)
+	}
+}
+
+// SetHistoricalFallback sets the maximum number of previous business days
+// (from Monday to Friday) in which the bank looks for an exchange rate when it
+// is missing at the requested date. For example with a fallback of 1, the
+// exchange rate of Sunday is looked up in the table of Sunday and then in the
+// table of Friday. A value of 0, the default, disables the fallback. It has no
+// effect if the bank is a snapshot.
+func (bank *Bank) SetHistoricalFallback(businessDays int) {
+	if bank.readOnly {
+		return
+	}
+	bank.mu.Lock()
+	defer bank.mu.Unlock()
+	bank.historicalFallbackDays = businessDays
+}
+
+// GetExchangeRateAt returns the exchange rate to convert the currency with ISO
+// code fromCurrencyIsoCode to the currency with ISO code toCurrencyIsoCode at
+// the given date. It is a conveniently wrapper of GetDecimalExchangeRateAt that
+// returns the nearest float64 of the exchange rate.
+func (bank *Bank) GetExchangeRateAt(fromCurrencyIsoCode, toCurrencyIsoCode string, date time.Time) (float64, error) {
+	rate, err := bank.GetDecimalExchangeRateAt(fromCurrencyIsoCode, toCurrencyIsoCode, date)
+	return rate.Float64(), err
+}
+
+// GetDecimalExchangeRateAt returns the exact exchange rate to convert the
+// currency with ISO code fromCurrencyIsoCode to the currency with ISO code
+// toCurrencyIsoCode at the given date, using the historical exchange rates
+// tables of the bank and the configured fallback (see SetHistoricalFallback).
+// Missing exchange rates are triangulated as in GetExchangeRatePath. Returns an
+// error if no historical table has the exchange rate.
+func (bank *Bank) GetDecimalExchangeRateAt(fromCurrencyIsoCode, toCurrencyIsoCode string, date time.Time) (Decimal, error) {
+	if fromCurrencyIsoCode == toCurrencyIsoCode {
+		return NewDecimal(1, 1), nil
+	}
+	bank.mu.RLock()
+	defer bank.mu.RUnlock()
+	day := date
+	for i := 0; i <= bank.historicalFallbackDays; i++ {
+		table, found := bank.historicalExchangeRatesTables[dateKey(day)]
+		if found {
+			rate, path := bank.findExchangeRate(table, fromCurrencyIsoCode, toCurrencyIsoCode)
+			if path != nil {
+				return rate, nil
+			}
+		}
+		day = previousBusinessDay(day)
+	}
+	return Decimal{}, &RateUnavailableError{From: fromCurrencyIsoCode, To: toCurrencyIsoCode, Date: &date}
+}
+
+// Private functions
+
+func dateKey(date time.Time) string {
+	return date.Format("2006-01-02")
+}
+
+func previousBusinessDay(date time.Time) time.Time {
+	date = date.AddDate(0, 0, -1)
+	for date.Weekday() == time.Saturday || date.Weekday() == time.Sunday {
+		date = date.AddDate(0, 0, -1)
+	}
+	return date
+}
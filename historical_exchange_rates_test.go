@@ -0,0 +1,79 @@
+package money_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pioz/money"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetExchangeRateAt(t *testing.T) {
+	bank, err := money.NewBankFromStaticExchangeRatesTable([]money.Currency{money.EUR, money.USD}, nil)
+	assert.Nil(t, err)
+
+	friday := time.Date(2021, time.October, 15, 0, 0, 0, 0, time.UTC)
+	monday := time.Date(2021, time.October, 18, 0, 0, 0, 0, time.UTC)
+	bank.LoadHistoricalExchangeRatesTables(map[time.Time]money.ExchangeRatesTable{
+		friday: {"EUR": {"USD": money.MustParseDecimal("1.16"), "GBP": money.MustParseDecimal("0.84")}},
+		monday: {"EUR": {"USD": money.MustParseDecimal("1.17")}},
+	})
+
+	r, err := bank.GetExchangeRateAt("EUR", "USD", monday.Add(15*time.Hour))
+	assert.Nil(t, err)
+	assert.Equal(t, 1.17, r)
+
+	r, err = bank.GetExchangeRateAt("EUR", "EUR", monday)
+	assert.Nil(t, err)
+	assert.Equal(t, 1.0, r)
+
+	_, err = bank.GetExchangeRateAt("EUR", "GBP", friday)
+	assert.NotNil(t, err)
+	assert.Equal(t, "bank does not support exchange from EUR to GBP at 2021-10-15", err.Error())
+
+	sunday := monday.AddDate(0, 0, -1)
+	_, err = bank.GetExchangeRateAt("EUR", "USD", sunday)
+	assert.NotNil(t, err)
+	assert.Equal(t, "bank does not support exchange from EUR to USD at 2021-10-17", err.Error())
+
+	bank.SetHistoricalFallback(1)
+	r, err = bank.GetExchangeRateAt("EUR", "USD", sunday)
+	assert.Nil(t, err)
+	assert.Equal(t, 1.16, r)
+
+	_, err = bank.GetExchangeRateAt("EUR", "USD", friday.AddDate(0, 0, -1))
+	assert.NotNil(t, err)
+	assert.Equal(t, "bank does not support exchange from EUR to USD at 2021-10-14", err.Error())
+
+	_, err = bank.GetExchangeRateAt("USD", "EUR", monday)
+	assert.NotNil(t, err)
+	assert.Equal(t, "bank does not support exchange from USD to EUR at 2021-10-18", err.Error())
+}
+
+func TestExchangeToAt(t *testing.T) {
+	bank, err := money.NewBankFromStaticExchangeRatesTable([]money.Currency{money.EUR, money.USD}, money.ExchangeRatesTableFromFloat(map[string]map[string]float64{
+		"EUR": {"USD": 1.5},
+	}))
+	assert.Nil(t, err)
+	date := time.Date(2021, time.October, 15, 0, 0, 0, 0, time.UTC)
+	bank.SetHistoricalExchangeRatesTable(date, money.ExchangeRatesTableFromFloat(map[string]map[string]float64{"EUR": {"USD": 1.2}}))
+
+	m, err := bank.NewMoney(100, "EUR")
+	assert.Nil(t, err)
+
+	ex, err := m.ExchangeToAt("USD", date)
+	assert.Nil(t, err)
+	assert.Equal(t, "$1.20", ex.Format())
+
+	ex, err = m.ExchangeTo("USD")
+	assert.Nil(t, err)
+	assert.Equal(t, "$1.50", ex.Format())
+
+	ex, err = m.ExchangeToAt("EUR", date)
+	assert.Nil(t, err)
+	assert.Equal(t, "€1,00", ex.Format())
+
+	_, err = m.ExchangeToAt("USD", date.AddDate(0, 0, 1))
+	assert.NotNil(t, err)
+	assert.Equal(t, "bank does not support exchange from EUR to USD at 2021-10-16", err.Error())
+}
@@ -0,0 +1,74 @@
+package money
+
+// SetInverseExchangeRates enables or disables the automatic derivation of
+// inverse exchange rates. When enabled, for each exchange rate X→Y published
+// in a fetched exchange rates table that does not publish also Y→X, the bank
+// derives Y→X as 1/(X→Y). A derived exchange rate never replaces a rate
+// published in the same table, while it replaces a rate published by a
+// previous update only if override is true. When enabled, the inverse
+// exchange rates are derived immediately from the current exchange rates
+// table; when disabled, all derived exchange rates are removed. It has no
+// effect if the bank is a snapshot.
+func (bank *Bank) SetInverseExchangeRates(enabled, override bool) {
+	if bank.readOnly {
+		return
+	}
+	bank.mu.Lock()
+	defer bank.mu.Unlock()
+	bank.inverseExchangeRates = enabled
+	bank.overridePublishedRates = override
+	if !enabled {
+		for fromCurrencyIsoCode, toCurrencies := range bank.derivedExchangeRates {
+			for toCurrencyIsoCode := range toCurrencies {
+				delete(bank.ExchangeRatesTable[fromCurrencyIsoCode], toCurrencyIsoCode)
+			}
+		}
+		bank.derivedExchangeRates = make(map[string]map[string]bool)
+		return
+	}
+	published := make(ExchangeRatesTable)
+	for fromCurrencyIsoCode, rates := range bank.ExchangeRatesTable {
+		published[fromCurrencyIsoCode] = make(ExchangeRates)
+		for toCurrencyIsoCode, rate := range rates {
+			if !bank.derivedExchangeRates[fromCurrencyIsoCode][toCurrencyIsoCode] {
+				published[fromCurrencyIsoCode][toCurrencyIsoCode] = rate
+			}
+		}
+	}
+	bank.deriveInverseExchangeRates(published, false)
+}
+
+// IsDerivedExchangeRate returns true if the exchange rate to convert the
+// currency with ISO code fromCurrencyIsoCode to the currency with ISO code
+// toCurrencyIsoCode has been derived as inverse of a published exchange rate
+// (see SetInverseExchangeRates) instead of being published.
+func (bank *Bank) IsDerivedExchangeRate(fromCurrencyIsoCode, toCurrencyIsoCode string) bool {
+	bank.mu.RLock()
+	defer bank.mu.RUnlock()
+	return bank.derivedExchangeRates[fromCurrencyIsoCode][toCurrencyIsoCode]
+}
+
+// Private functions
+
+// deriveInverseExchangeRates sets in the bank exchange rates table the inverse
+// of the exchange rates of published. The caller must hold bank.mu.
+func (bank *Bank) deriveInverseExchangeRates(published ExchangeRatesTable, override bool) {
+	// The inverse exchange rates of a compact table are computed on lookup
+	if bank.baseCurrencyIsoCode != "" {
+		return
+	}
+	bank.eachSupportedExchangeRate(published, func(fromCurrencyIsoCode, toCurrencyIsoCode string, rate Decimal) {
+		if rate.IsZero() || !published[toCurrencyIsoCode][fromCurrencyIsoCode].IsZero() {
+			return
+		}
+		current := bank.ExchangeRatesTable[toCurrencyIsoCode][fromCurrencyIsoCode]
+		if !current.IsZero() && !bank.derivedExchangeRates[toCurrencyIsoCode][fromCurrencyIsoCode] && !override {
+			return
+		}
+		bank.ExchangeRatesTable[toCurrencyIsoCode][fromCurrencyIsoCode] = rate.Inv()
+		if bank.derivedExchangeRates[toCurrencyIsoCode] == nil {
+			bank.derivedExchangeRates[toCurrencyIsoCode] = make(map[string]bool)
+		}
+		bank.derivedExchangeRates[toCurrencyIsoCode][fromCurrencyIsoCode] = true
+	})
+}
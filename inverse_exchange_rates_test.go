@@ -0,0 +1,78 @@
+package money_test
+
+import (
+	"testing"
+
+	"github.com/pioz/money"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInverseExchangeRates(t *testing.T) {
+	tables := []money.ExchangeRatesTable{
+		money.ExchangeRatesTableFromFloat(map[string]map[string]float64{"EUR": {"USD": 1.25, "GBP": 0.8}, "GBP": {"EUR": 1.2}}),
+		money.ExchangeRatesTableFromFloat(map[string]map[string]float64{"EUR": {"USD": 2}}),
+	}
+	counter := 0
+	bank, err := money.NewBank([]money.Currency{money.EUR, money.USD, money.GBP}, func() (money.ExchangeRatesTable, error) {
+		table := tables[counter]
+		counter++
+		return table, nil
+	}, nil)
+	assert.Nil(t, err)
+
+	_, err = bank.GetExchangeRate("USD", "EUR")
+	assert.NotNil(t, err)
+
+	bank.SetInverseExchangeRates(true, false)
+
+	rate, err := bank.GetExchangeRate("USD", "EUR")
+	assert.Nil(t, err)
+	assert.Equal(t, 0.8, rate)
+	assert.True(t, bank.IsDerivedExchangeRate("USD", "EUR"))
+	assert.False(t, bank.IsDerivedExchangeRate("EUR", "USD"))
+
+	rate, err = bank.GetExchangeRate("GBP", "EUR")
+	assert.Nil(t, err)
+	assert.Equal(t, 1.2, rate)
+	assert.False(t, bank.IsDerivedExchangeRate("GBP", "EUR"))
+
+	err = bank.UpdateExchangeRatesTable()
+	assert.Nil(t, err)
+
+	rate, err = bank.GetExchangeRate("USD", "EUR")
+	assert.Nil(t, err)
+	assert.Equal(t, 0.5, rate)
+	assert.True(t, bank.IsDerivedExchangeRate("USD", "EUR"))
+
+	rate, err = bank.GetExchangeRate("GBP", "EUR")
+	assert.Nil(t, err)
+	assert.Equal(t, 1.2, rate)
+
+	bank.SetInverseExchangeRates(false, false)
+	_, err = bank.GetExchangeRate("USD", "EUR")
+	assert.NotNil(t, err)
+	assert.False(t, bank.IsDerivedExchangeRate("USD", "EUR"))
+}
+
+func TestInverseExchangeRatesOverride(t *testing.T) {
+	tables := []money.ExchangeRatesTable{
+		money.ExchangeRatesTableFromFloat(map[string]map[string]float64{"GBP": {"EUR": 1.2}}),
+		money.ExchangeRatesTableFromFloat(map[string]map[string]float64{"EUR": {"GBP": 0.5}}),
+	}
+	counter := 0
+	bank, err := money.NewBank([]money.Currency{money.EUR, money.GBP}, func() (money.ExchangeRatesTable, error) {
+		table := tables[counter]
+		counter++
+		return table, nil
+	}, nil)
+	assert.Nil(t, err)
+
+	bank.SetInverseExchangeRates(true, true)
+	err = bank.UpdateExchangeRatesTable()
+	assert.Nil(t, err)
+
+	rate, err := bank.GetExchangeRate("GBP", "EUR")
+	assert.Nil(t, err)
+	assert.Equal(t, 2.0, rate)
+	assert.True(t, bank.IsDerivedExchangeRate("GBP", "EUR"))
+}
@@ -0,0 +1,101 @@
+package money
+
+import (
+	"fmt"
+	"log"
+	"strings"
+)
+
+// Logger is the interface used by the bank to log its events, like the fetch
+// of the exchange rates table or the failure to write the cache. args are
+// alternating keys and values with the structured fields of the event, for
+// example "provider", "freecurrencyapi", "error", err. The method set is the
+// same of *slog.Logger, so a *slog.Logger can be used as Logger.
+type Logger interface {
+	Debug(msg string, args ...interface{})
+	Info(msg string, args ...interface{})
+	Warn(msg string, args ...interface{})
+	Error(msg string, args ...interface{})
+}
+
+// SetLogger sets the logger used by the bank. The default logger writes only
+// warnings and errors with the standard log package. A nil logger disables the
+// logging. It has no effect if the bank is a snapshot.
+func (bank *Bank) SetLogger(logger Logger) {
+	if bank.readOnly {
+		return
+	}
+	if logger == nil {
+		logger = nopLogger{}
+	}
+	bank.mu.Lock()
+	defer bank.mu.Unlock()
+	bank.logger = logger
+}
+
+// SetProviderName sets the name of the provider of the exchange rates table,
+// added to the logged events with the key "provider". It has no effect if the
+// bank is a snapshot.
+func (bank *Bank) SetProviderName(name string) {
+	if bank.readOnly {
+		return
+	}
+	bank.mu.Lock()
+	defer bank.mu.Unlock()
+	bank.providerName = name
+}
+
+// Private functions
+
+func (bank *Bank) getLogger() Logger {
+	bank.mu.RLock()
+	defer bank.mu.RUnlock()
+	return bank.logger
+}
+
+func (bank *Bank) getProviderName() string {
+	bank.mu.RLock()
+	defer bank.mu.RUnlock()
+	return bank.providerName
+}
+
+// stdLogger logs warnings and errors with the standard log package.
+type stdLogger struct{}
+
+func (stdLogger) Debug(msg string, args ...interface{}) {}
+
+func (stdLogger) Info(msg string, args ...interface{}) {}
+
+func (stdLogger) Warn(msg string, args ...interface{}) {
+	log.Println(formatLogEvent("WARN", msg, args))
+}
+
+func (stdLogger) Error(msg string, args ...interface{}) {
+	log.Println(formatLogEvent("ERROR", msg, args))
+}
+
+// nopLogger discards all events.
+type nopLogger struct{}
+
+func (nopLogger) Debug(msg string, args ...interface{}) {}
+
+func (nopLogger) Info(msg string, args ...interface{}) {}
+
+func (nopLogger) Warn(msg string, args ...interface{}) {}
+
+func (nopLogger) Error(msg string, args ...interface{}) {}
+
+func formatLogEvent(level, msg string, args []interface{}) string {
+	var b strings.Builder
+	b.WriteString(level)
+	b.WriteString(" ")
+	b.WriteString(msg)
+	for i := 0; i < len(args); i += 2 {
+		if i+1 < len(args) {
+			fmt.Fprintf(&b, " %v=%v", args[i], args[i+1])
+		} else {
+			fmt.Fprintf(&b, " %v", args[i])
+		}
+	}
+	return b.String()
+}
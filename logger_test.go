@@ -0,0 +1,97 @@
+package money_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/pioz/money"
+	"github.com/stretchr/testify/assert"
+)
+
+type logEvent struct {
+	level string
+	msg   string
+	args  []interface{}
+}
+
+type recordLogger struct {
+	mu     sync.Mutex
+	events []logEvent
+}
+
+func (l *recordLogger) record(level, msg string, args []interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.events = append(l.events, logEvent{level: level, msg: msg, args: args})
+}
+
+func (l *recordLogger) Debug(msg string, args ...interface{}) { l.record("DEBUG", msg, args) }
+func (l *recordLogger) Info(msg string, args ...interface{})  { l.record("INFO", msg, args) }
+func (l *recordLogger) Warn(msg string, args ...interface{})  { l.record("WARN", msg, args) }
+func (l *recordLogger) Error(msg string, args ...interface{}) { l.record("ERROR", msg, args) }
+
+func TestSetLogger(t *testing.T) {
+	fail := true
+	bank, err := money.NewBank([]money.Currency{money.EUR, money.USD}, func() (money.ExchangeRatesTable, error) {
+		if fail {
+			return nil, errors.New("provider is down")
+		}
+		return money.ExchangeRatesTableFromFloat(map[string]map[string]float64{"EUR": {"USD": 1.2}}), nil
+	}, nil)
+	assert.NotNil(t, err)
+
+	logger := &recordLogger{}
+	bank.SetLogger(logger)
+	bank.SetProviderName("test")
+
+	err = bank.UpdateExchangeRatesTable()
+	assert.NotNil(t, err)
+	fail = false
+	err = bank.UpdateExchangeRatesTable()
+	assert.Nil(t, err)
+
+	assert.Equal(t, 2, len(logger.events))
+	// The error is returned, so it is not logged as error
+	assert.Equal(t, "INFO", logger.events[0].level)
+	assert.Equal(t, "failed to fetch exchange rates table", logger.events[0].msg)
+	assert.Equal(t, "provider", logger.events[0].args[0])
+	assert.Equal(t, "test", logger.events[0].args[1])
+	assert.Equal(t, "duration", logger.events[0].args[2])
+	assert.Equal(t, "error", logger.events[0].args[4])
+	assert.Equal(t, "provider is down", fmt.Sprint(logger.events[0].args[5]))
+	assert.Equal(t, "INFO", logger.events[1].level)
+	assert.Equal(t, "exchange rates table fetched", logger.events[1].msg)
+	assert.Equal(t, []interface{}{"rates", 1}, logger.events[1].args[4:])
+}
+
+func TestDefaultLogger(t *testing.T) {
+	buf := new(bytes.Buffer)
+	log.SetOutput(buf)
+	log.SetFlags(0)
+	defer log.SetOutput(os.Stderr)
+	defer log.SetFlags(log.LstdFlags)
+
+	bank, err := money.NewBank([]money.Currency{money.EUR, money.USD}, func() (money.ExchangeRatesTable, error) {
+		return money.ExchangeRatesTableFromFloat(map[string]map[string]float64{"EUR": {"USD": 1.2}}), nil
+	}, money.ExchangeRatesTableFileCache{FilePath: "/tmp/not-found/not-found"})
+	assert.Nil(t, err)
+	assert.Equal(t, "ERROR failed to write exchange rates table cache provider= rates=1 error=lock cache file /tmp/not-found/not-found: open /tmp/not-found/not-found.lock: no such file or directory\n", buf.String())
+
+	buf.Reset()
+	bank.SetLogger(nil)
+	err = bank.UpdateExchangeRatesTable()
+	assert.Nil(t, err)
+	assert.Equal(t, "", buf.String())
+
+	// Errors returned to the caller are not logged
+	_, err = money.NewBank([]money.Currency{money.EUR, money.USD}, func() (money.ExchangeRatesTable, error) {
+		return nil, errors.New("provider is down")
+	}, nil)
+	assert.NotNil(t, err)
+	assert.Equal(t, "", buf.String())
+}
@@ -6,11 +6,11 @@ package money
 
 import (
 	"bytes"
-	"errors"
 	"fmt"
 	"math"
 	"strconv"
 	"strings"
+	"time"
 )
 
 // Money represents a monetary value in a specific currency.
@@ -24,38 +24,73 @@ type Money struct {
 
 // ExchangeTo creates a new money in the currency with ISO code currencyIsoCode
 // converted from m, using the exchange rate value stored in the bank exchange
-// rates table. Returns an error if the currencyIsoCode is not supported by the
-// current bank or if the bank is not able to exchange m.Currency to
-// currencyIsoCode.
+// rates table adjusted by the bank spread (see Bank.SetSpread). Returns an
+// error if the currencyIsoCode is not supported by the current bank or if the
+// bank is not able to exchange m.Currency to currencyIsoCode.
 func (m *Money) ExchangeTo(currencyIsoCode string) (*Money, error) {
 	if m.Currency == currencyIsoCode {
 		return m.bank.NewMoney(m.Cents, m.Currency)
 	}
-	rate, err := m.bank.GetExchangeRate(m.Currency, currencyIsoCode)
+	rate, err := m.bank.GetDecimalExchangeRate(m.Currency, currencyIsoCode)
 	if err != nil {
 		return nil, err
 	}
-	fromCurrency := m.bank.Currencies[m.Currency]
-	toCurrency := m.bank.Currencies[currencyIsoCode]
-	fractional := float64(m.Cents) / (float64(fromCurrency.SubunitToUnit) / float64(toCurrency.SubunitToUnit))
-	exchangedCents := int(math.Round(fractional * rate))
-	return m.bank.NewMoney(exchangedCents, currencyIsoCode)
+	return m.ExchangeToWithDecimalRate(currencyIsoCode, m.bank.applySpread(m.Currency, currencyIsoCode, rate))
 }
 
-// ExchangeTo creates a new money in the currency with ISO code currencyIsoCode
-// converted from m, using the given exchange rate. Returns an error if the
-// currencyIsoCode is not supported by the current bank.
+// ExchangeToAt creates a new money in the currency with ISO code
+// currencyIsoCode converted from m, using the exchange rate that the bank had
+// at the given date adjusted by the bank spread, like ExchangeTo. See
+// Bank.GetExchangeRateAt for more details. Returns an
+// error if the currencyIsoCode is not supported by the current bank or if the
+// bank is not able to exchange m.Currency to currencyIsoCode at date.
+func (m *Money) ExchangeToAt(currencyIsoCode string, date time.Time) (*Money, error) {
+	if m.Currency == currencyIsoCode {
+		return m.bank.NewMoney(m.Cents, m.Currency)
+	}
+	rate, err := m.bank.GetDecimalExchangeRateAt(m.Currency, currencyIsoCode, date)
+	if err != nil {
+		return nil, err
+	}
+	return m.ExchangeToWithDecimalRate(currencyIsoCode, m.bank.applySpread(m.Currency, currencyIsoCode, rate))
+}
+
+// ExchangeToWithRate creates a new money in the currency with ISO code
+// currencyIsoCode converted from m, using the given exchange rate. It is a
+// conveniently wrapper of ExchangeToWithDecimalRate, so rate is converted to
+// Decimal with DecimalFromFloat. Returns an error if the currencyIsoCode is
+// not supported by the current bank or if rate is NaN or infinite.
 func (m *Money) ExchangeToWithRate(currencyIsoCode string, rate float64) (*Money, error) {
+	decimalRate, err := DecimalFromFloat(rate)
+	if err != nil {
+		return nil, err
+	}
+	return m.ExchangeToWithDecimalRate(currencyIsoCode, decimalRate)
+}
+
+// ExchangeToWithDecimalRate creates a new money in the currency with ISO code
+// currencyIsoCode converted from m, using the given exact exchange rate.
+// Returns an error if the currencyIsoCode is not supported by the current bank.
+func (m *Money) ExchangeToWithDecimalRate(currencyIsoCode string, rate Decimal) (*Money, error) {
 	if m.Currency == currencyIsoCode {
 		return m.bank.NewMoney(m.Cents, m.Currency)
 	}
-	fromCurrency := m.bank.Currencies[m.Currency]
-	toCurrency := m.bank.Currencies[currencyIsoCode]
-	fractional := float64(m.Cents) / (float64(fromCurrency.SubunitToUnit) / float64(toCurrency.SubunitToUnit))
-	exchangedCents := int(math.Round(fractional * rate))
+	_, err := m.bank.getCurrency(currencyIsoCode)
+	if err != nil {
+		return nil, err
+	}
+	exchangedCents, _ := m.exchangeCents(currencyIsoCode, rate, m.bank.GetRoundingMode())
 	return m.bank.NewMoney(exchangedCents, currencyIsoCode)
 }
 
+// Bind returns a copy of m bound to bank, so that all the operations of the
+// copy use the currencies and the exchange rates of bank. It is helpful to
+// bind money to a bank snapshot (see Bank.Snapshot). Returns an error if bank
+// does not support m's currency.
+func (m *Money) Bind(bank *Bank) (*Money, error) {
+	return bank.NewMoney(m.Cents, m.Currency)
+}
+
 // Equals returns true if m1 and m2 have the same monetaty value. If m2's
 // currency is different from m1's currency, m2 will be exchanged to m1's
 // currency. Returns error if the bank that generated m1 is different from the
@@ -175,7 +210,7 @@ func (m *Money) Multiply(mul int) *Money {
 // pennies than ones that are listed later.
 func (m *Money) Split(parts int) ([]*Money, error) {
 	if parts <= 0 {
-		return nil, errors.New("split must be higher than zero")
+		return nil, ErrInvalidSplit
 	}
 	cents := m.Cents / parts
 	remainder := m.Cents % parts
@@ -208,11 +243,29 @@ func (m *Money) Format() string {
 
 // Private functions
 
+// exchangeCents returns the fractional value of m exchanged to the currency
+// with ISO code currencyIsoCode using rate and rounded with mode, and the
+// rounding residue, that is the unrounded value less the rounded one.
+func (m *Money) exchangeCents(currencyIsoCode string, rate Decimal, mode RoundingMode) (int, Decimal) {
+	fromCurrency := m.bank.Currencies[m.Currency]
+	toCurrency := m.bank.Currencies[currencyIsoCode]
+	exchanged := NewDecimal(int64(m.Cents), 1).Mul(rate).Mul(NewDecimal(int64(toCurrency.SubunitToUnit), int64(fromCurrency.SubunitToUnit)))
+	rounded := mode.round(exchanged)
+	return rounded, exchanged.Sub(NewDecimal(int64(rounded), 1))
+}
+
+// prepareOperation exchanges m2 to the currency of m1 at the mid-market
+// exchange rate, so that operations and comparisons are not affected by the
+// bank spread and give the same result in both directions.
 func prepareOperation(m1, m2 *Money) (*Money, error) {
 	if m1.bank != m2.bank {
-		return nil, fmt.Errorf("currencies have different banks: operation between currencies can be done only between currencies of the same bank")
+		return nil, &BankMismatchError{Currency: m1.Currency, OtherCurrency: m2.Currency}
+	}
+	rate, err := m2.bank.GetDecimalExchangeRate(m2.Currency, m1.Currency)
+	if err != nil {
+		return nil, err
 	}
-	return m2.ExchangeTo(m1.Currency)
+	return m2.ExchangeToWithDecimalRate(m1.Currency, rate)
 }
 
 func commaf(v float64, thousandsSeparator, decimalMark rune, precision int) string {
@@ -2,6 +2,7 @@ package money_test
 
 import (
 	"fmt"
+	"math"
 	"testing"
 
 	"github.com/pioz/money"
@@ -9,10 +10,10 @@ import (
 )
 
 func TestExchange(t *testing.T) {
-	bank, err := money.NewBankFromStaticExchangeRatesTable([]money.Currency{money.EUR, money.USD, money.JPY}, money.ExchangeRatesTable{
+	bank, err := money.NewBankFromStaticExchangeRatesTable([]money.Currency{money.EUR, money.USD, money.JPY}, money.ExchangeRatesTableFromFloat(map[string]map[string]float64{
 		"EUR": {"EUR": 1.1, "USD": 1.2, "JPY": 103.3},
 		"USD": {"EUR": 1.4, "JPY": 103.3},
-	})
+	}))
 	assert.Nil(t, err)
 
 	m, err := bank.NewMoney(100, "EUR")
@@ -45,10 +46,10 @@ func TestExchange(t *testing.T) {
 }
 
 func ExampleMoney_ExchangeTo() {
-	bank, _ := money.NewBankFromStaticExchangeRatesTable([]money.Currency{money.EUR, money.USD}, money.ExchangeRatesTable{
+	bank, _ := money.NewBankFromStaticExchangeRatesTable([]money.Currency{money.EUR, money.USD}, money.ExchangeRatesTableFromFloat(map[string]map[string]float64{
 		"EUR": {"USD": 1.154321},
 		"USD": {"EUR": 0.86702},
-	})
+	}))
 	usd, _ := bank.NewMoney(100, "USD")
 	eur, _ := usd.ExchangeTo("EUR")
 	fmt.Println(eur.Format())
@@ -70,10 +71,14 @@ func TestExchangeToWithRate(t *testing.T) {
 	ex, err = m.ExchangeToWithRate("USD", 1.2)
 	assert.Nil(t, err)
 	assert.Equal(t, "$1.20", ex.Format())
+
+	_, err = m.ExchangeToWithRate("USD", math.NaN())
+	assert.NotNil(t, err)
+	assert.Equal(t, "invalid decimal NaN", err.Error())
 }
 
 func TestEquals(t *testing.T) {
-	bank, err := money.NewBankFromStaticExchangeRatesTable([]money.Currency{money.EUR, money.USD}, money.ExchangeRatesTable{"USD": {"EUR": 0.8}})
+	bank, err := money.NewBankFromStaticExchangeRatesTable([]money.Currency{money.EUR, money.USD}, money.ExchangeRatesTableFromFloat(map[string]map[string]float64{"USD": {"EUR": 0.8}}))
 	assert.Nil(t, err)
 
 	m1, err := bank.NewMoney(100, "EUR")
@@ -110,7 +115,7 @@ func TestEquals(t *testing.T) {
 }
 
 func TestGreaterThan(t *testing.T) {
-	bank, err := money.NewBankFromStaticExchangeRatesTable([]money.Currency{money.EUR, money.USD}, money.ExchangeRatesTable{"USD": {"EUR": 0.8}})
+	bank, err := money.NewBankFromStaticExchangeRatesTable([]money.Currency{money.EUR, money.USD}, money.ExchangeRatesTableFromFloat(map[string]map[string]float64{"USD": {"EUR": 0.8}}))
 	assert.Nil(t, err)
 
 	m1, err := bank.NewMoney(100, "EUR")
@@ -153,7 +158,7 @@ func TestGreaterThan(t *testing.T) {
 }
 
 func TestGreaterThanOrEqual(t *testing.T) {
-	bank, err := money.NewBankFromStaticExchangeRatesTable([]money.Currency{money.EUR, money.USD}, money.ExchangeRatesTable{"USD": {"EUR": 0.8}})
+	bank, err := money.NewBankFromStaticExchangeRatesTable([]money.Currency{money.EUR, money.USD}, money.ExchangeRatesTableFromFloat(map[string]map[string]float64{"USD": {"EUR": 0.8}}))
 	assert.Nil(t, err)
 
 	m1, err := bank.NewMoney(100, "EUR")
@@ -196,7 +201,7 @@ func TestGreaterThanOrEqual(t *testing.T) {
 }
 
 func TestLessThan(t *testing.T) {
-	bank, err := money.NewBankFromStaticExchangeRatesTable([]money.Currency{money.EUR, money.USD}, money.ExchangeRatesTable{"USD": {"EUR": 0.8}})
+	bank, err := money.NewBankFromStaticExchangeRatesTable([]money.Currency{money.EUR, money.USD}, money.ExchangeRatesTableFromFloat(map[string]map[string]float64{"USD": {"EUR": 0.8}}))
 	assert.Nil(t, err)
 
 	m1, err := bank.NewMoney(100, "EUR")
@@ -239,7 +244,7 @@ func TestLessThan(t *testing.T) {
 }
 
 func TestLessThanOrEqual(t *testing.T) {
-	bank, err := money.NewBankFromStaticExchangeRatesTable([]money.Currency{money.EUR, money.USD}, money.ExchangeRatesTable{"USD": {"EUR": 0.8}})
+	bank, err := money.NewBankFromStaticExchangeRatesTable([]money.Currency{money.EUR, money.USD}, money.ExchangeRatesTableFromFloat(map[string]map[string]float64{"USD": {"EUR": 0.8}}))
 	assert.Nil(t, err)
 
 	m1, err := bank.NewMoney(100, "EUR")
@@ -331,7 +336,7 @@ func TestAbsolute(t *testing.T) {
 }
 
 func TestAdd(t *testing.T) {
-	bank, err := money.NewBankFromStaticExchangeRatesTable([]money.Currency{money.EUR, money.USD}, money.ExchangeRatesTable{"USD": {"EUR": 0.8}})
+	bank, err := money.NewBankFromStaticExchangeRatesTable([]money.Currency{money.EUR, money.USD}, money.ExchangeRatesTableFromFloat(map[string]map[string]float64{"USD": {"EUR": 0.8}}))
 	assert.Nil(t, err)
 
 	m1, err := bank.NewMoney(100, "EUR")
@@ -360,7 +365,7 @@ func TestAdd(t *testing.T) {
 }
 
 func TestSubtract(t *testing.T) {
-	bank, err := money.NewBankFromStaticExchangeRatesTable([]money.Currency{money.EUR, money.USD}, money.ExchangeRatesTable{"USD": {"EUR": 0.8}})
+	bank, err := money.NewBankFromStaticExchangeRatesTable([]money.Currency{money.EUR, money.USD}, money.ExchangeRatesTableFromFloat(map[string]map[string]float64{"USD": {"EUR": 0.8}}))
 	assert.Nil(t, err)
 
 	m1, err := bank.NewMoney(100, "EUR")
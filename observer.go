@@ -0,0 +1,101 @@
+package money
+
+import "sort"
+
+// ExchangeRateChange represents the change of an exchange rate after an update
+// of the exchange rates table.
+type ExchangeRateChange struct {
+	// ISO code of the currency to convert from.
+	From string
+	// ISO code of the currency to convert to.
+	To string
+	// Exchange rate before the update, zero if the exchange rate is new.
+	Old Decimal
+	// Exchange rate after the update, zero if the exchange rate has been
+	// removed.
+	New Decimal
+}
+
+// AbsoluteChange returns the absolute change of the exchange rate, that is
+// New - Old.
+func (change ExchangeRateChange) AbsoluteChange() Decimal {
+	return change.New.Sub(change.Old)
+}
+
+// RelativeChange returns the relative change of the exchange rate, that is
+// (New - Old) / Old. For example 0.02 means that the exchange rate rose by 2%.
+// Returns 0 if the exchange rate is new.
+func (change ExchangeRateChange) RelativeChange() float64 {
+	if change.Old.IsZero() {
+		return 0
+	}
+	return change.New.Sub(change.Old).Quo(change.Old).Float64()
+}
+
+// ExchangeRatesTableObserver is the signature of the function called after
+// each successful update of the bank exchange rates table. It receives a copy
+// of the table before and after the update, and the changed exchange rates.
+// The tables of a bank that stores them in compact form are the full tables
+// derived from the base currency (see Bank.SetCompactExchangeRates).
+type ExchangeRatesTableObserver func(oldTable, newTable ExchangeRatesTable, changes []ExchangeRateChange)
+
+// FetchErrorHandler is the signature of the function called when the bank fails
+// to fetch the exchange rates table.
+type FetchErrorHandler func(err error)
+
+// OnExchangeRatesTableUpdate registers observer to be called after each
+// successful update of the exchange rates table. Observers are called in order
+// of registration, in the goroutine that updated the table.
+func (bank *Bank) OnExchangeRatesTableUpdate(observer ExchangeRatesTableObserver) {
+	bank.mu.Lock()
+	defer bank.mu.Unlock()
+	bank.updateObservers = append(bank.updateObservers, observer)
+}
+
+// OnFetchError registers handler to be called each time the fetch of the
+// exchange rates table fails, including the fetch made in background when the
+// table is loaded from cache.
+func (bank *Bank) OnFetchError(handler FetchErrorHandler) {
+	bank.mu.Lock()
+	defer bank.mu.Unlock()
+	bank.fetchErrorHandlers = append(bank.fetchErrorHandlers, handler)
+}
+
+// Private functions
+
+func (bank *Bank) notifyFetchError(err error) {
+	bank.mu.RLock()
+	handlers := bank.fetchErrorHandlers
+	bank.mu.RUnlock()
+	for _, handler := range handlers {
+		handler(err)
+	}
+}
+
+// diffExchangeRatesTables returns the exchange rates that are different in
+// oldTable and newTable, sorted by currency ISO codes.
+func diffExchangeRatesTables(oldTable, newTable ExchangeRatesTable) []ExchangeRateChange {
+	changes := make([]ExchangeRateChange, 0)
+	for fromCurrencyIsoCode, rates := range newTable {
+		for toCurrencyIsoCode, rate := range rates {
+			oldRate := oldTable[fromCurrencyIsoCode][toCurrencyIsoCode]
+			if oldRate.Cmp(rate) != 0 {
+				changes = append(changes, ExchangeRateChange{From: fromCurrencyIsoCode, To: toCurrencyIsoCode, Old: oldRate, New: rate})
+			}
+		}
+	}
+	for fromCurrencyIsoCode, rates := range oldTable {
+		for toCurrencyIsoCode, rate := range rates {
+			if _, found := newTable[fromCurrencyIsoCode][toCurrencyIsoCode]; !found && !rate.IsZero() {
+				changes = append(changes, ExchangeRateChange{From: fromCurrencyIsoCode, To: toCurrencyIsoCode, Old: rate})
+			}
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		if changes[i].From != changes[j].From {
+			return changes[i].From < changes[j].From
+		}
+		return changes[i].To < changes[j].To
+	})
+	return changes
+}
@@ -0,0 +1,93 @@
+package money_test
+
+import (
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/pioz/money"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOnExchangeRatesTableUpdate(t *testing.T) {
+	tables := []money.ExchangeRatesTable{
+		money.ExchangeRatesTableFromFloat(map[string]map[string]float64{"EUR": {"USD": 1.2, "GBP": 0.8}}),
+		money.ExchangeRatesTableFromFloat(map[string]map[string]float64{"EUR": {"USD": 1.23, "GBP": 0.8}, "USD": {"EUR": 0.8}}),
+	}
+	counter := 0
+	bank, err := money.NewBank([]money.Currency{money.EUR, money.USD, money.GBP}, func() (money.ExchangeRatesTable, error) {
+		table := tables[counter]
+		counter++
+		return table, nil
+	}, nil)
+	assert.Nil(t, err)
+
+	var oldTable, newTable money.ExchangeRatesTable
+	var changes []money.ExchangeRateChange
+	calls := 0
+	bank.OnExchangeRatesTableUpdate(func(o, n money.ExchangeRatesTable, c []money.ExchangeRateChange) {
+		oldTable, newTable, changes = o, n, c
+		calls++
+	})
+	bank.OnExchangeRatesTableUpdate(func(o, n money.ExchangeRatesTable, c []money.ExchangeRateChange) {
+		calls++
+	})
+
+	err = bank.UpdateExchangeRatesTable()
+	assert.Nil(t, err)
+	assert.Equal(t, 2, calls)
+	assert.Equal(t, "1.2", oldTable["EUR"]["USD"].String())
+	assert.Equal(t, "1.23", newTable["EUR"]["USD"].String())
+	assert.Equal(t, 2, len(changes))
+	assert.Equal(t, "EUR", changes[0].From)
+	assert.Equal(t, "USD", changes[0].To)
+	assert.Equal(t, "1.2", changes[0].Old.String())
+	assert.Equal(t, "1.23", changes[0].New.String())
+	assert.InDelta(t, 0.025, changes[0].RelativeChange(), 1e-9)
+	assert.Equal(t, "USD", changes[1].From)
+	assert.Equal(t, "EUR", changes[1].To)
+	assert.True(t, changes[1].Old.IsZero())
+	assert.Equal(t, 0.0, changes[1].RelativeChange())
+}
+
+func TestOnFetchError(t *testing.T) {
+	fileCache := money.ExchangeRatesTableFileCache{FilePath: "/tmp/go-money-fetch-error-cache"}
+	defer os.RemoveAll(fileCache.FilePath)
+	err := fileCache.Write(money.ExchangeRatesTableFromFloat(map[string]map[string]float64{"EUR": {"USD": 1.2}}))
+	assert.Nil(t, err)
+
+	errs := make(chan error, 2)
+	f := func() (money.ExchangeRatesTable, error) {
+		return nil, errors.New("provider is down")
+	}
+	bank, err := money.NewBankWithOptions(
+		money.WithCurrencies([]money.Currency{money.EUR, money.USD}),
+		money.WithFetcher(f),
+		money.WithCache(fileCache),
+		money.WithLogger(nil),
+		money.WithNonBlockingStartup(),
+		money.WithRetryInterval(time.Hour),
+		money.WithFetchErrorHandler(func(err error) {
+			errs <- err
+		}),
+	)
+	assert.Nil(t, err)
+	defer bank.Close()
+
+	select {
+	case err = <-errs:
+		assert.Equal(t, "provider is down", err.Error())
+	case <-time.After(time.Second):
+		t.Fatal("fetch error handler not called")
+	}
+
+	bank, err = money.NewBank([]money.Currency{money.EUR, money.USD}, f, nil)
+	assert.NotNil(t, err)
+	bank.OnFetchError(func(err error) {
+		errs <- err
+	})
+	err = bank.UpdateExchangeRatesTable()
+	assert.NotNil(t, err)
+	assert.Equal(t, "provider is down", (<-errs).Error())
+}
@@ -0,0 +1,355 @@
+package money
+
+import (
+	"errors"
+	"time"
+)
+
+// A BankOption configures a bank created with NewBankWithOptions.
+type BankOption func(*bankOptions)
+
+type bankOptions struct {
+	currencies      []Currency
+	fetch           FetchExchangeRatesTableFunc
+	cache           ExchangeRatesTableCache
+	roundingMode    RoundingMode
+	maxStaleness    time.Duration
+	logger          Logger
+	loggerSet       bool
+	providerName    string
+	pivotIsoCode    string
+	refreshInterval time.Duration
+	retryInterval   time.Duration
+	nonBlocking     bool
+	seed            ExchangeRatesTable
+	validationRules *ValidationRules
+	cacheMaxAge     time.Duration
+	compactIsoCode  string
+	observers       []ExchangeRatesTableObserver
+	errorHandlers   []FetchErrorHandler
+}
+
+// DefaultRetryInterval is the default interval between the fetch attempts of
+// a bank started with WithNonBlockingStartup, until the first fetch succeeds.
+const DefaultRetryInterval = 30 * time.Second
+
+// WithCurrencies sets the currencies supported by the bank. The default is
+// money.AllCurrencies.
+func WithCurrencies(currencies []Currency) BankOption {
+	return func(options *bankOptions) {
+		options.currencies = currencies
+	}
+}
+
+// WithFetcher sets the function used to fetch the exchange rates table.
+func WithFetcher(fetch FetchExchangeRatesTableFunc) BankOption {
+	return func(options *bankOptions) {
+		options.fetch = fetch
+	}
+}
+
+// WithCache sets the cache of the exchange rates table (see NewBank).
+func WithCache(cache ExchangeRatesTableCache) BankOption {
+	return func(options *bankOptions) {
+		options.cache = cache
+	}
+}
+
+// WithCacheMaxAge sets the maximum age of the cached exchange rates table
+// (see Bank.SetCacheMaxAge).
+func WithCacheMaxAge(maxAge time.Duration) BankOption {
+	return func(options *bankOptions) {
+		options.cacheMaxAge = maxAge
+	}
+}
+
+// WithRoundingMode sets the rounding mode of the exchanges (see
+// Bank.SetRoundingMode).
+func WithRoundingMode(mode RoundingMode) BankOption {
+	return func(options *bankOptions) {
+		options.roundingMode = mode
+	}
+}
+
+// WithMaxStaleness sets the maximum age of the exchange rates table (see
+// Bank.SetMaxStaleness).
+func WithMaxStaleness(maxStaleness time.Duration) BankOption {
+	return func(options *bankOptions) {
+		options.maxStaleness = maxStaleness
+	}
+}
+
+// WithLogger sets the logger of the bank (see Bank.SetLogger).
+func WithLogger(logger Logger) BankOption {
+	return func(options *bankOptions) {
+		options.logger = logger
+		options.loggerSet = true
+	}
+}
+
+// WithProviderName sets the name of the provider of the exchange rates table
+// (see Bank.SetProviderName). Unlike Bank.SetProviderName, the name is also
+// used to log the first fetch.
+func WithProviderName(name string) BankOption {
+	return func(options *bankOptions) {
+		options.providerName = name
+	}
+}
+
+// WithPivotCurrency sets the pivot currency of the bank (see
+// Bank.SetPivotCurrency).
+func WithPivotCurrency(currencyIsoCode string) BankOption {
+	return func(options *bankOptions) {
+		options.pivotIsoCode = currencyIsoCode
+	}
+}
+
+// WithCompactExchangeRates makes the bank store the exchange rates table in
+// compact form, as the single row of the base currency with ISO code
+// baseIsoCode (see Bank.SetCompactExchangeRates).
+func WithCompactExchangeRates(baseIsoCode string) BankOption {
+	return func(options *bankOptions) {
+		options.compactIsoCode = baseIsoCode
+	}
+}
+
+// WithObserver registers observer to be called after each successful update
+// of the exchange rates table (see Bank.OnExchangeRatesTableUpdate). Unlike
+// Bank.OnExchangeRatesTableUpdate, the observer is registered before the
+// first update, so it is called also for the seeded, cached or fetched table
+// set by NewBankWithOptions. It can be used several times.
+func WithObserver(observer ExchangeRatesTableObserver) BankOption {
+	return func(options *bankOptions) {
+		options.observers = append(options.observers, observer)
+	}
+}
+
+// WithFetchErrorHandler registers handler to be called each time the fetch of
+// the exchange rates table fails (see Bank.OnFetchError). Unlike
+// Bank.OnFetchError, the handler is registered before the first fetch, so it
+// is called also if the first fetch, even in background, fails. It can be used
+// several times.
+func WithFetchErrorHandler(handler FetchErrorHandler) BankOption {
+	return func(options *bankOptions) {
+		options.errorHandlers = append(options.errorHandlers, handler)
+	}
+}
+
+// WithRefreshInterval makes the bank refresh the exchange rates table in
+// background every interval, until Bank.Close is called. Refresh errors are
+// logged and notified to the fetch error handlers (see Bank.OnFetchError).
+func WithRefreshInterval(interval time.Duration) BankOption {
+	return func(options *bankOptions) {
+		options.refreshInterval = interval
+	}
+}
+
+// WithNonBlockingStartup makes NewBankWithOptions return without waiting for
+// the first fetch of the exchange rates table, that is done in background. If
+// there is a cache, the exchange rates table is loaded from it before
+// returning. The bank is usable even if the first fetch fails: the error is
+// logged and notified to the fetch error handlers, and the fetch is retried in
+// background (see WithRetryInterval) until it succeeds. Meanwhile the bank is
+// in degraded mode (see Bank.IsDegraded): it exchanges with the cached or
+// seeded exchange rates (see WithSeedExchangeRatesTable) and, if there are
+// none, the exchanges fail with a RateUnavailableError caused by
+// ErrExchangeRatesNotAvailable.
+func WithNonBlockingStartup() BankOption {
+	return func(options *bankOptions) {
+		options.nonBlocking = true
+	}
+}
+
+// WithRetryInterval sets the interval between the fetch attempts of a bank
+// started with WithNonBlockingStartup, until the first fetch succeeds. The
+// default is DefaultRetryInterval.
+func WithRetryInterval(interval time.Duration) BankOption {
+	return func(options *bankOptions) {
+		options.retryInterval = interval
+	}
+}
+
+// WithSeedExchangeRatesTable sets the exchange rates table used by the bank
+// until it is replaced by the cache or the fetch function, for example a
+// static table embedded in the program. Seeded exchange rates have source
+// RateSourceSeed and they are never written to the cache.
+func WithSeedExchangeRatesTable(table ExchangeRatesTable) BankOption {
+	return func(options *bankOptions) {
+		options.seed = table
+	}
+}
+
+// WithValidationRules sets the rules used to validate each new exchange rates
+// table (see Bank.SetValidationRules). A seeded table that violates the rules
+// makes NewBankWithOptions fail.
+func WithValidationRules(rules ValidationRules) BankOption {
+	return func(options *bankOptions) {
+		options.validationRules = &rules
+	}
+}
+
+// NewBankWithOptions creates a new bank configured by opts. Without options it
+// creates a bank that supports all currencies and that is unable to exchange
+// them, like DefaultBank. Returns an error if an option is not valid. Unless
+// WithNonBlockingStartup is used, it fetches the exchange rates table like
+// NewBank and returns the bank together with the error if the fetch fails; in
+// this case the background refresh is not started.
+func NewBankWithOptions(opts ...BankOption) (*Bank, error) {
+	options := bankOptions{currencies: AllCurrencies, retryInterval: DefaultRetryInterval}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	if options.refreshInterval < 0 {
+		return nil, errors.New("refresh interval must not be negative")
+	}
+	if options.maxStaleness < 0 {
+		return nil, errors.New("max staleness must not be negative")
+	}
+	if options.cacheMaxAge < 0 {
+		return nil, errors.New("cache max age must not be negative")
+	}
+	if options.retryInterval <= 0 {
+		return nil, errors.New("retry interval must be positive")
+	}
+
+	bank := newBank(options.currencies, options.fetch, options.cache)
+	err := bank.SetPivotCurrency(options.pivotIsoCode)
+	if err != nil {
+		return nil, err
+	}
+	err = bank.SetCompactExchangeRates(options.compactIsoCode)
+	if err != nil {
+		return nil, err
+	}
+	bank.SetRoundingMode(options.roundingMode)
+	bank.SetMaxStaleness(options.maxStaleness)
+	bank.SetCacheMaxAge(options.cacheMaxAge)
+	bank.SetProviderName(options.providerName)
+	if options.loggerSet {
+		bank.SetLogger(options.logger)
+	}
+	err = bank.SetValidationRules(options.validationRules)
+	if err != nil {
+		return nil, err
+	}
+	for _, observer := range options.observers {
+		bank.OnExchangeRatesTableUpdate(observer)
+	}
+	for _, handler := range options.errorHandlers {
+		bank.OnFetchError(handler)
+	}
+
+	if options.seed != nil {
+		err = bank.setExchangeRatesTable(options.seed, RateSourceSeed, time.Now())
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if options.nonBlocking {
+		if options.fetch != nil {
+			bank.nonBlockingStartup = true
+			bank.stopRefresh = make(chan struct{})
+			bank.loadExchangeRatesTableCache()
+			go bank.fetchUntilSuccess(options.retryInterval, bank.stopRefresh)
+		}
+	} else {
+		err = bank.UpdateExchangeRatesTable()
+		if err != nil {
+			return bank, err
+		}
+	}
+	if options.refreshInterval > 0 && options.fetch != nil {
+		if bank.stopRefresh == nil {
+			bank.stopRefresh = make(chan struct{})
+		}
+		go bank.refreshEvery(options.refreshInterval, bank.stopRefresh)
+	}
+	return bank, nil
+}
+
+// SetMaxStaleness sets the maximum age of the exchange rates table. When the
+// last update of the table is older than maxStaleness, the bank refuses to
+// exchange with it and returns a RateUnavailableError caused by
+// ErrStaleExchangeRates. Historical exchange rates are not affected. Zero, the
+// default, disables the check. It has no effect if the bank is a snapshot.
+func (bank *Bank) SetMaxStaleness(maxStaleness time.Duration) {
+	if bank.readOnly {
+		return
+	}
+	bank.mu.Lock()
+	defer bank.mu.Unlock()
+	bank.maxStaleness = maxStaleness
+}
+
+// IsDegraded returns true if the bank has a fetch function, but the fetch of
+// the exchange rates table has not yet succeeded, so the bank exchanges with
+// the cached or seeded exchange rates, if any (see WithNonBlockingStartup).
+func (bank *Bank) IsDegraded() bool {
+	bank.mu.RLock()
+	defer bank.mu.RUnlock()
+	return bank.fetchExchangeRatesTable != nil && !bank.fetched
+}
+
+// Close stops the background fetches of the exchange rates table started by
+// WithRefreshInterval and WithNonBlockingStartup. It has no effect if there
+// are no background fetches.
+func (bank *Bank) Close() error {
+	bank.closeOnce.Do(func() {
+		bank.mu.Lock()
+		defer bank.mu.Unlock()
+		if bank.stopRefresh != nil {
+			close(bank.stopRefresh)
+		}
+	})
+	return nil
+}
+
+// Private functions
+
+// refreshEvery updates the exchange rates table every interval until stop is
+// closed. Errors are logged and notified to the fetch error handlers.
+func (bank *Bank) refreshEvery(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			_ = bank.blockingUpdateExchangeRatesTable(true)
+		case <-stop:
+			return
+		}
+	}
+}
+
+// fetchUntilSuccess updates the exchange rates table every retryInterval until
+// an update succeeds or stop is closed. It stops retrying also when the table
+// is updated in the meanwhile in another way, for example by a call to
+// UpdateExchangeRatesTable. Errors are logged and notified to the fetch error
+// handlers.
+func (bank *Bank) fetchUntilSuccess(retryInterval time.Duration, stop <-chan struct{}) {
+	bank.mu.RLock()
+	startedUpdatedAt := bank.exchangeRatesUpdatedAt
+	bank.mu.RUnlock()
+	for bank.blockingUpdateExchangeRatesTable(true) != nil {
+		timer := time.NewTimer(retryInterval)
+		select {
+		case <-timer.C:
+		case <-stop:
+			timer.Stop()
+			return
+		}
+		bank.mu.RLock()
+		updated := !bank.exchangeRatesUpdatedAt.Equal(startedUpdatedAt)
+		bank.mu.RUnlock()
+		if updated {
+			return
+		}
+	}
+}
+
+// isStale returns true if the exchange rates table is older than the maximum
+// staleness. The caller must hold bank.mu.
+func (bank *Bank) isStale() bool {
+	return bank.maxStaleness > 0 && time.Since(bank.exchangeRatesUpdatedAt) > bank.maxStaleness
+}
@@ -0,0 +1,294 @@
+package money_test
+
+import (
+	"errors"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pioz/money"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewBankWithOptions(t *testing.T) {
+	logger := &recordLogger{}
+	bank, err := money.NewBankWithOptions(
+		money.WithCurrencies([]money.Currency{money.EUR, money.USD, money.JPY}),
+		money.WithFetcher(func() (money.ExchangeRatesTable, error) {
+			return money.ExchangeRatesTableFromFloat(map[string]map[string]float64{
+				"EUR": {"USD": 1.2},
+				"USD": {"JPY": 110},
+			}), nil
+		}),
+		money.WithRoundingMode(money.RoundDown),
+		money.WithPivotCurrency("USD"),
+		money.WithLogger(logger),
+		money.WithProviderName("test"),
+	)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(bank.Currencies))
+	assert.Equal(t, money.RoundDown, bank.GetRoundingMode())
+
+	rate, path, err := bank.GetExchangeRatePath("EUR", "JPY")
+	assert.Nil(t, err)
+	assert.Equal(t, "132", rate.String())
+	assert.Equal(t, []string{"EUR", "USD", "JPY"}, path)
+
+	assert.Equal(t, 1, len(logger.events))
+	assert.Equal(t, "exchange rates table fetched", logger.events[0].msg)
+	assert.Equal(t, []interface{}{"provider", "test"}, logger.events[0].args[0:2])
+}
+
+func TestNewBankWithOptionsDefaults(t *testing.T) {
+	bank, err := money.NewBankWithOptions()
+	assert.Nil(t, err)
+	assert.Equal(t, len(money.AllCurrencies), len(bank.Currencies))
+	assert.Equal(t, money.RoundHalfAwayFromZero, bank.GetRoundingMode())
+	_, err = bank.GetExchangeRate("EUR", "USD")
+	assert.True(t, errors.Is(err, money.ErrRateUnavailable))
+}
+
+func TestNewBankWithOptionsInvalidOptions(t *testing.T) {
+	bank, err := money.NewBankWithOptions(money.WithCurrencies([]money.Currency{money.EUR}), money.WithPivotCurrency("USD"))
+	assert.Nil(t, bank)
+	assert.True(t, errors.Is(err, money.ErrUnsupportedCurrency))
+
+	bank, err = money.NewBankWithOptions(money.WithRefreshInterval(-time.Second))
+	assert.Nil(t, bank)
+	assert.EqualError(t, err, "refresh interval must not be negative")
+
+	bank, err = money.NewBankWithOptions(money.WithMaxStaleness(-time.Second))
+	assert.Nil(t, bank)
+	assert.EqualError(t, err, "max staleness must not be negative")
+}
+
+func TestNewBankWithOptionsFetchError(t *testing.T) {
+	bank, err := money.NewBankWithOptions(
+		money.WithCurrencies([]money.Currency{money.EUR, money.USD}),
+		money.WithFetcher(func() (money.ExchangeRatesTable, error) {
+			return nil, errors.New("provider is down")
+		}),
+		money.WithLogger(nil),
+	)
+	assert.NotNil(t, bank)
+	assert.EqualError(t, err, "provider is down")
+}
+
+func TestNewBankWithOptionsNonBlockingStartup(t *testing.T) {
+	release := make(chan struct{})
+	fetched := make(chan error, 1)
+	var fail int32 = 1
+	bank, err := money.NewBankWithOptions(
+		money.WithCurrencies([]money.Currency{money.EUR, money.USD}),
+		money.WithFetcher(func() (money.ExchangeRatesTable, error) {
+			<-release
+			if atomic.LoadInt32(&fail) == 1 {
+				return nil, errors.New("provider is down")
+			}
+			return money.ExchangeRatesTableFromFloat(map[string]map[string]float64{"EUR": {"USD": 1.2}}), nil
+		}),
+		money.WithLogger(nil),
+		money.WithNonBlockingStartup(),
+		money.WithFetchErrorHandler(func(err error) {
+			fetched <- err
+		}),
+	)
+	assert.Nil(t, err)
+	close(release)
+	assert.EqualError(t, <-fetched, "provider is down")
+
+	_, err = bank.GetExchangeRate("EUR", "USD")
+	assert.True(t, errors.Is(err, money.ErrRateUnavailable))
+	assert.True(t, errors.Is(err, money.ErrExchangeRatesNotAvailable))
+	assert.True(t, bank.IsDegraded())
+
+	atomic.StoreInt32(&fail, 0)
+	err = bank.UpdateExchangeRatesTable()
+	assert.Nil(t, err)
+	rate, err := bank.GetExchangeRate("EUR", "USD")
+	assert.Nil(t, err)
+	assert.Equal(t, 1.2, rate)
+	assert.False(t, bank.IsDegraded())
+	assert.Nil(t, bank.Close())
+}
+
+func TestNewBankWithOptionsNonBlockingStartupRetry(t *testing.T) {
+	var fetches int32
+	updated := make(chan struct{}, 1)
+	bank, err := money.NewBankWithOptions(
+		money.WithCurrencies([]money.Currency{money.EUR, money.USD}),
+		money.WithFetcher(func() (money.ExchangeRatesTable, error) {
+			if atomic.AddInt32(&fetches, 1) <= 3 {
+				return nil, errors.New("provider is down")
+			}
+			return money.ExchangeRatesTableFromFloat(map[string]map[string]float64{"EUR": {"USD": 1.2}}), nil
+		}),
+		money.WithLogger(nil),
+		money.WithNonBlockingStartup(),
+		money.WithRetryInterval(time.Millisecond),
+		money.WithObserver(func(oldTable, newTable money.ExchangeRatesTable, changes []money.ExchangeRateChange) {
+			updated <- struct{}{}
+		}),
+	)
+	assert.Nil(t, err)
+	defer bank.Close()
+	<-updated
+
+	rate, err := bank.GetExchangeRate("EUR", "USD")
+	assert.Nil(t, err)
+	assert.Equal(t, 1.2, rate)
+	assert.False(t, bank.IsDegraded())
+	// No more fetches after the first success
+	time.Sleep(10 * time.Millisecond)
+	assert.Equal(t, int32(4), atomic.LoadInt32(&fetches))
+}
+
+func TestNewBankWithOptionsNonBlockingStartupStopsRetryAfterUpdate(t *testing.T) {
+	var fetches, fail int32 = 0, 1
+	failed := make(chan struct{}, 10)
+	bank, err := money.NewBankWithOptions(
+		money.WithCurrencies([]money.Currency{money.EUR, money.USD}),
+		money.WithFetcher(func() (money.ExchangeRatesTable, error) {
+			atomic.AddInt32(&fetches, 1)
+			if atomic.LoadInt32(&fail) == 1 {
+				return nil, errors.New("provider is down")
+			}
+			return money.ExchangeRatesTableFromFloat(map[string]map[string]float64{"EUR": {"USD": 1.2}}), nil
+		}),
+		money.WithLogger(nil),
+		money.WithNonBlockingStartup(),
+		money.WithRetryInterval(5*time.Millisecond),
+		money.WithFetchErrorHandler(func(err error) {
+			select {
+			case failed <- struct{}{}:
+			default:
+			}
+		}),
+	)
+	assert.Nil(t, err)
+	defer bank.Close()
+	<-failed
+
+	atomic.StoreInt32(&fail, 0)
+	err = bank.UpdateExchangeRatesTable()
+	assert.Nil(t, err)
+	atomic.StoreInt32(&fail, 1)
+	fetchesAfterUpdate := atomic.LoadInt32(&fetches)
+	time.Sleep(20 * time.Millisecond)
+	// At most the retry in progress during the update
+	assert.LessOrEqual(t, atomic.LoadInt32(&fetches), fetchesAfterUpdate+1)
+}
+
+func TestNewBankWithOptionsBlockingStartupFailure(t *testing.T) {
+	bank, err := money.NewBankWithOptions(
+		money.WithCurrencies([]money.Currency{money.EUR, money.USD}),
+		money.WithFetcher(func() (money.ExchangeRatesTable, error) {
+			return nil, errors.New("provider is down")
+		}),
+		money.WithLogger(nil),
+	)
+	assert.EqualError(t, err, "provider is down")
+	_, err = bank.GetExchangeRate("EUR", "USD")
+	assert.True(t, errors.Is(err, money.ErrRateUnavailable))
+	assert.False(t, errors.Is(err, money.ErrExchangeRatesNotAvailable))
+}
+
+func TestNewBankWithOptionsSeedExchangeRatesTable(t *testing.T) {
+	fileCache := money.ExchangeRatesTableFileCache{FilePath: "/tmp/go-money-seed-cache"}
+	defer os.RemoveAll(fileCache.FilePath)
+	bank, err := money.NewBankWithOptions(
+		money.WithCurrencies([]money.Currency{money.EUR, money.USD, money.JPY}),
+		money.WithFetcher(func() (money.ExchangeRatesTable, error) {
+			return nil, errors.New("provider is down")
+		}),
+		money.WithCache(fileCache),
+		money.WithLogger(nil),
+		money.WithSeedExchangeRatesTable(money.ExchangeRatesTableFromFloat(map[string]map[string]float64{"EUR": {"USD": 1.1}})),
+		money.WithNonBlockingStartup(),
+	)
+	assert.Nil(t, err)
+	defer bank.Close()
+	assert.True(t, bank.IsDegraded())
+
+	eur, _ := bank.NewMoney(100, "EUR")
+	receipt, err := eur.ExchangeWithReceipt("USD")
+	assert.Nil(t, err)
+	assert.Equal(t, 110, receipt.Target.Cents)
+	assert.Equal(t, money.RateSourceSeed, receipt.RateSource)
+
+	// Missing exchange rates fail as usual
+	_, err = bank.GetExchangeRate("EUR", "JPY")
+	assert.True(t, errors.Is(err, money.ErrRateUnavailable))
+	assert.False(t, errors.Is(err, money.ErrExchangeRatesNotAvailable))
+
+	// Seeded exchange rates are not written to the cache
+	_, err = os.Stat(fileCache.FilePath)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestNewBankWithOptionsInvalidRetryInterval(t *testing.T) {
+	bank, err := money.NewBankWithOptions(money.WithRetryInterval(0))
+	assert.Nil(t, bank)
+	assert.EqualError(t, err, "retry interval must be positive")
+}
+
+func TestNewBankWithOptionsRefreshInterval(t *testing.T) {
+	var fetches int32
+	updated := make(chan struct{}, 10)
+	bank, err := money.NewBankWithOptions(
+		money.WithCurrencies([]money.Currency{money.EUR, money.USD}),
+		money.WithFetcher(func() (money.ExchangeRatesTable, error) {
+			n := atomic.AddInt32(&fetches, 1)
+			return money.ExchangeRatesTableFromFloat(map[string]map[string]float64{"EUR": {"USD": float64(n)}}), nil
+		}),
+		money.WithRefreshInterval(time.Millisecond),
+	)
+	assert.Nil(t, err)
+	bank.OnExchangeRatesTableUpdate(func(oldTable, newTable money.ExchangeRatesTable, changes []money.ExchangeRateChange) {
+		select {
+		case updated <- struct{}{}:
+		default:
+		}
+	})
+	<-updated
+	<-updated
+	assert.Nil(t, bank.Close())
+	assert.Nil(t, bank.Close())
+
+	fetchesAfterClose := atomic.LoadInt32(&fetches)
+	time.Sleep(10 * time.Millisecond)
+	// At most the refresh in progress during Close
+	assert.LessOrEqual(t, atomic.LoadInt32(&fetches), fetchesAfterClose+1)
+	assert.GreaterOrEqual(t, fetchesAfterClose, int32(3))
+}
+
+func TestSetMaxStaleness(t *testing.T) {
+	bank, err := money.NewBankWithOptions(
+		money.WithCurrencies([]money.Currency{money.EUR, money.USD}),
+		money.WithFetcher(func() (money.ExchangeRatesTable, error) {
+			return money.ExchangeRatesTableFromFloat(map[string]map[string]float64{"EUR": {"USD": 1.2}}), nil
+		}),
+		money.WithMaxStaleness(time.Hour),
+	)
+	assert.Nil(t, err)
+	rate, err := bank.GetExchangeRate("EUR", "USD")
+	assert.Nil(t, err)
+	assert.Equal(t, 1.2, rate)
+
+	bank.SetMaxStaleness(time.Nanosecond)
+	time.Sleep(time.Millisecond)
+	_, err = bank.GetExchangeRate("EUR", "USD")
+	assert.True(t, errors.Is(err, money.ErrRateUnavailable))
+	assert.True(t, errors.Is(err, money.ErrStaleExchangeRates))
+	assert.EqualError(t, err, "bank does not support exchange from EUR to USD: exchange rates table is stale")
+
+	// Exchanges to the same currency do not need the exchange rates table
+	rate, err = bank.GetExchangeRate("EUR", "EUR")
+	assert.Nil(t, err)
+	assert.Equal(t, 1.0, rate)
+
+	bank.SetMaxStaleness(0)
+	_, err = bank.GetExchangeRate("EUR", "USD")
+	assert.Nil(t, err)
+}
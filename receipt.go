@@ -0,0 +1,77 @@
+package money
+
+import "time"
+
+const (
+	// RateSourceFetch is the source of exchange rates fetched by the bank fetch
+	// function.
+	RateSourceFetch = "fetch"
+	// RateSourceCache is the source of exchange rates read from the bank cache.
+	RateSourceCache = "cache"
+	// RateSourceSeed is the source of exchange rates seeded at startup (see
+	// WithSeedExchangeRatesTable).
+	RateSourceSeed = "seed"
+)
+
+// ExchangeReceipt records how an exchange of money has been done, so that it
+// can be audited later. It is the result of Money.ExchangeWithReceipt and it
+// can be serialized to JSON.
+type ExchangeReceipt struct {
+	// Exchanged money.
+	Source *Money `json:"source"`
+	// Result of the exchange.
+	Target *Money `json:"target"`
+	// Mid-market exchange rate.
+	MidMarketRate Decimal `json:"mid_market_rate"`
+	// Exchange rate used, that is the mid-market exchange rate adjusted by the
+	// bank spread.
+	Rate Decimal `json:"rate"`
+	// Currency ISO codes of the path used to get the mid-market exchange rate
+	// (see Bank.GetExchangeRatePath).
+	RatePath []string `json:"rate_path"`
+	// Source of the exchange rates table of the bank: RateSourceFetch,
+	// RateSourceCache or RateSourceSeed.
+	RateSource string `json:"rate_source"`
+	// Time of the last update of the exchange rates table of the bank.
+	RateTime time.Time `json:"rate_time"`
+	// Rounding mode used to round the exchanged value.
+	RoundingMode RoundingMode `json:"rounding_mode"`
+	// Difference, in the smallest unit of the target currency, between the
+	// exchanged value before and after the rounding.
+	RoundingResidue Decimal `json:"rounding_residue"`
+}
+
+// ExchangeWithReceipt exchanges m to the currency with ISO code currencyIsoCode
+// like ExchangeTo, but it returns a receipt with the result and the details of
+// the exchange. Returns an error if the currencyIsoCode is not supported by
+// the current bank or if the bank is not able to exchange m.Currency to
+// currencyIsoCode.
+func (m *Money) ExchangeWithReceipt(currencyIsoCode string) (*ExchangeReceipt, error) {
+	midMarketRate, path, err := m.bank.GetExchangeRatePath(m.Currency, currencyIsoCode)
+	if err != nil {
+		return nil, err
+	}
+	rate := midMarketRate
+	if m.Currency != currencyIsoCode {
+		rate = m.bank.applySpread(m.Currency, currencyIsoCode, midMarketRate)
+	}
+	m.bank.mu.RLock()
+	receipt := &ExchangeReceipt{
+		MidMarketRate: midMarketRate,
+		Rate:          rate,
+		RatePath:      path,
+		RateSource:    m.bank.exchangeRatesSource,
+		RateTime:      m.bank.exchangeRatesUpdatedAt,
+		RoundingMode:  m.bank.roundingMode,
+	}
+	m.bank.mu.RUnlock()
+
+	var cents int
+	cents, receipt.RoundingResidue = m.exchangeCents(currencyIsoCode, rate, receipt.RoundingMode)
+	receipt.Target, err = m.bank.NewMoney(cents, currencyIsoCode)
+	if err != nil {
+		return nil, err
+	}
+	receipt.Source, _ = m.bank.NewMoney(m.Cents, m.Currency)
+	return receipt, nil
+}
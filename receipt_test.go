@@ -0,0 +1,55 @@
+package money_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/pioz/money"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExchangeWithReceipt(t *testing.T) {
+	bank, err := money.NewBankFromStaticExchangeRatesTable([]money.Currency{money.EUR, money.USD}, money.ExchangeRatesTableFromFloat(map[string]map[string]float64{
+		"EUR": {"USD": 1.234},
+	}))
+	assert.Nil(t, err)
+	m, err := bank.NewMoney(1000, "EUR")
+	assert.Nil(t, err)
+
+	receipt, err := m.ExchangeWithReceipt("USD")
+	assert.Nil(t, err)
+	assert.Equal(t, 1000, receipt.Source.Cents)
+	assert.Equal(t, "EUR", receipt.Source.Currency)
+	assert.Equal(t, 1234, receipt.Target.Cents)
+	assert.Equal(t, "USD", receipt.Target.Currency)
+	assert.Equal(t, "1.234", receipt.MidMarketRate.String())
+	assert.Equal(t, "1.234", receipt.Rate.String())
+	assert.Equal(t, []string{"EUR", "USD"}, receipt.RatePath)
+	assert.Equal(t, money.RateSourceFetch, receipt.RateSource)
+	assert.False(t, receipt.RateTime.IsZero())
+	assert.Equal(t, money.RoundHalfAwayFromZero, receipt.RoundingMode)
+	assert.True(t, receipt.RoundingResidue.IsZero())
+
+	m, err = bank.NewMoney(10, "EUR")
+	assert.Nil(t, err)
+	bank.SetRoundingMode(money.RoundDown)
+	receipt, err = m.ExchangeWithReceipt("USD")
+	assert.Nil(t, err)
+	assert.Equal(t, 12, receipt.Target.Cents)
+	assert.Equal(t, "0.34", receipt.RoundingResidue.String())
+
+	data, err := json.Marshal(receipt)
+	assert.Nil(t, err)
+	var decoded map[string]interface{}
+	err = json.Unmarshal(data, &decoded)
+	assert.Nil(t, err)
+	assert.Equal(t, "down", decoded["rounding_mode"])
+	assert.Equal(t, 1.234, decoded["rate"])
+	assert.Equal(t, 0.34, decoded["rounding_residue"])
+	assert.Equal(t, "fetch", decoded["rate_source"])
+	assert.Equal(t, []interface{}{"EUR", "USD"}, decoded["rate_path"])
+
+	_, err = m.ExchangeWithReceipt("JPY")
+	assert.NotNil(t, err)
+	assert.Equal(t, "bank does not support exchange from EUR to JPY", err.Error())
+}
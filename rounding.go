@@ -0,0 +1,105 @@
+package money
+
+import (
+	"fmt"
+	"math/big"
+)
+
+// RoundingMode defines how the bank rounds the fractional value of exchanged
+// money to an integer number of the smallest unit of the currency.
+type RoundingMode int
+
+const (
+	// RoundHalfAwayFromZero rounds to the nearest integer, rounding half away
+	// from zero. It is the default rounding mode.
+	RoundHalfAwayFromZero RoundingMode = iota
+	// RoundHalfEven rounds to the nearest integer, rounding half to even
+	// (banker's rounding).
+	RoundHalfEven
+	// RoundDown rounds toward zero (truncation).
+	RoundDown
+	// RoundUp rounds away from zero.
+	RoundUp
+)
+
+var roundingModeNames = map[RoundingMode]string{
+	RoundHalfAwayFromZero: "half_away_from_zero",
+	RoundHalfEven:         "half_even",
+	RoundDown:             "down",
+	RoundUp:               "up",
+}
+
+// String returns the name of the rounding mode.
+func (mode RoundingMode) String() string {
+	name, found := roundingModeNames[mode]
+	if !found {
+		return fmt.Sprintf("RoundingMode(%d)", int(mode))
+	}
+	return name
+}
+
+// MarshalText implements the encoding.TextMarshaler interface.
+func (mode RoundingMode) MarshalText() ([]byte, error) {
+	name, found := roundingModeNames[mode]
+	if !found {
+		return nil, fmt.Errorf("invalid rounding mode %d", int(mode))
+	}
+	return []byte(name), nil
+}
+
+// UnmarshalText implements the encoding.TextUnmarshaler interface.
+func (mode *RoundingMode) UnmarshalText(text []byte) error {
+	for m, name := range roundingModeNames {
+		if name == string(text) {
+			*mode = m
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid rounding mode %q", text)
+}
+
+// SetRoundingMode sets the rounding mode used by the bank to exchange money.
+// It has no effect if the bank is a snapshot.
+func (bank *Bank) SetRoundingMode(mode RoundingMode) {
+	if bank.readOnly {
+		return
+	}
+	bank.mu.Lock()
+	defer bank.mu.Unlock()
+	bank.roundingMode = mode
+}
+
+// GetRoundingMode returns the rounding mode used by the bank to exchange money.
+func (bank *Bank) GetRoundingMode() RoundingMode {
+	bank.mu.RLock()
+	defer bank.mu.RUnlock()
+	return bank.roundingMode
+}
+
+// Private functions
+
+// round rounds x to an integer according to mode.
+func (mode RoundingMode) round(x Decimal) int {
+	num, denom := x.get().Num(), x.get().Denom()
+	quotient, remainder := new(big.Int).QuoRem(num, denom, new(big.Int))
+	if remainder.Sign() != 0 {
+		twice := new(big.Int).Abs(remainder)
+		twice.Lsh(twice, 1)
+		half := twice.Cmp(denom)
+		var awayFromZero bool
+		switch mode {
+		case RoundHalfEven:
+			awayFromZero = half > 0 || (half == 0 && quotient.Bit(0) == 1)
+		case RoundDown:
+			awayFromZero = false
+		case RoundUp:
+			awayFromZero = true
+		default:
+			awayFromZero = half >= 0
+		}
+		if awayFromZero {
+			quotient.Add(quotient, big.NewInt(int64(num.Sign())))
+		}
+	}
+	return int(quotient.Int64())
+}
@@ -0,0 +1,45 @@
+package money_test
+
+import (
+	"testing"
+
+	"github.com/pioz/money"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRoundingMode(t *testing.T) {
+	bank, err := money.NewBankFromStaticExchangeRatesTable([]money.Currency{money.EUR, money.USD}, money.ExchangeRatesTableFromFloat(map[string]map[string]float64{
+		"EUR": {"USD": 1.25},
+	}))
+	assert.Nil(t, err)
+	assert.Equal(t, money.RoundHalfAwayFromZero, bank.GetRoundingMode())
+
+	m, err := bank.NewMoney(10, "EUR")
+	assert.Nil(t, err)
+	negative, err := bank.NewMoney(-10, "EUR")
+	assert.Nil(t, err)
+
+	tests := []struct {
+		mode          money.RoundingMode
+		cents         int
+		negativeCents int
+		name          string
+	}{
+		{money.RoundHalfAwayFromZero, 13, -13, "half_away_from_zero"},
+		{money.RoundHalfEven, 12, -12, "half_even"},
+		{money.RoundDown, 12, -12, "down"},
+		{money.RoundUp, 13, -13, "up"},
+	}
+	for _, test := range tests {
+		bank.SetRoundingMode(test.mode)
+		assert.Equal(t, test.name, test.mode.String())
+		ex, err := m.ExchangeTo("USD")
+		assert.Nil(t, err)
+		assert.Equal(t, test.cents, ex.Cents)
+		ex, err = negative.ExchangeTo("USD")
+		assert.Nil(t, err)
+		assert.Equal(t, test.negativeCents, ex.Cents)
+	}
+
+	assert.Equal(t, "RoundingMode(42)", money.RoundingMode(42).String())
+}
@@ -0,0 +1,67 @@
+package money
+
+// Snapshot returns a read-only copy of the bank that freezes the current
+// exchange rates, the historical exchange rates and the exchange settings
+// (triangulation, spreads, conversion fee and rounding mode). Money created
+// by the snapshot, or bound to it with Money.Bind, does all its exchanges with
+// the frozen exchange rates, even if in the meanwhile the exchange rates table
+// of the bank is updated. So a calculation made of several operations uses
+// the same exchange rates for each step.
+//
+// A snapshot can not be modified: UpdateExchangeRatesTable and the setters that
+// return an error, like SetSpread or SetPivotCurrency, return ErrReadOnlyBank,
+// while the other setters, like SetRoundingMode or
+// SetHistoricalExchangeRatesTable, have no effect.
+func (bank *Bank) Snapshot() *Bank {
+	bank.mu.RLock()
+	defer bank.mu.RUnlock()
+	snapshot := &Bank{
+		Currencies:                    make(map[string]Currency, len(bank.Currencies)),
+		ExchangeRatesTable:            bank.ExchangeRatesTable.clone(),
+		historicalExchangeRatesTables: make(map[string]ExchangeRatesTable, len(bank.historicalExchangeRatesTables)),
+		historicalFallbackDays:        bank.historicalFallbackDays,
+		pivotCurrencyIsoCode:          bank.pivotCurrencyIsoCode,
+		baseCurrencyIsoCode:           bank.baseCurrencyIsoCode,
+		shortestPathTriangulation:     bank.shortestPathTriangulation,
+		inverseExchangeRates:          bank.inverseExchangeRates,
+		overridePublishedRates:        bank.overridePublishedRates,
+		derivedExchangeRates:          make(map[string]map[string]bool, len(bank.derivedExchangeRates)),
+		spreads:                       make(map[string]map[string]Spread, len(bank.spreads)),
+		defaultSpread:                 bank.defaultSpread,
+		defaultSpreadBase:             bank.defaultSpreadBase,
+		conversionFee:                 bank.conversionFee,
+		roundingMode:                  bank.roundingMode,
+		exchangeRatesSource:           bank.exchangeRatesSource,
+		exchangeRatesUpdatedAt:        bank.exchangeRatesUpdatedAt,
+		logger:                        bank.logger,
+		providerName:                  bank.providerName,
+		nonBlockingStartup:            bank.nonBlockingStartup,
+		readOnly:                      true,
+	}
+	for isoCode, currency := range bank.Currencies {
+		snapshot.Currencies[isoCode] = currency
+	}
+	// Historical tables are never modified, only replaced
+	for date, table := range bank.historicalExchangeRatesTables {
+		snapshot.historicalExchangeRatesTables[date] = table
+	}
+	for fromCurrencyIsoCode, toCurrencies := range bank.derivedExchangeRates {
+		snapshot.derivedExchangeRates[fromCurrencyIsoCode] = make(map[string]bool, len(toCurrencies))
+		for toCurrencyIsoCode, derived := range toCurrencies {
+			snapshot.derivedExchangeRates[fromCurrencyIsoCode][toCurrencyIsoCode] = derived
+		}
+	}
+	for baseIsoCode, quotes := range bank.spreads {
+		snapshot.spreads[baseIsoCode] = make(map[string]Spread, len(quotes))
+		for quoteIsoCode, spread := range quotes {
+			snapshot.spreads[baseIsoCode][quoteIsoCode] = spread
+		}
+	}
+	return snapshot
+}
+
+// IsSnapshot returns true if the bank is a read-only snapshot of another bank
+// (see Snapshot).
+func (bank *Bank) IsSnapshot() bool {
+	return bank.readOnly
+}
@@ -0,0 +1,129 @@
+package money_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/pioz/money"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSnapshot(t *testing.T) {
+	var counter int64
+	bank, err := money.NewBank([]money.Currency{money.EUR, money.USD}, func() (money.ExchangeRatesTable, error) {
+		counter++
+		return money.ExchangeRatesTable{"EUR": {"USD": money.NewDecimal(counter, 1)}}, nil
+	}, nil)
+	assert.Nil(t, err)
+	assert.False(t, bank.IsSnapshot())
+
+	snapshot := bank.Snapshot()
+	assert.True(t, snapshot.IsSnapshot())
+
+	err = bank.UpdateExchangeRatesTable()
+	assert.Nil(t, err)
+
+	m, err := snapshot.NewMoney(100, "EUR")
+	assert.Nil(t, err)
+	ex, err := m.ExchangeTo("USD")
+	assert.Nil(t, err)
+	assert.Equal(t, "$1.00", ex.Format())
+
+	live, err := bank.NewMoney(100, "EUR")
+	assert.Nil(t, err)
+	ex, err = live.ExchangeTo("USD")
+	assert.Nil(t, err)
+	assert.Equal(t, "$2.00", ex.Format())
+
+	bound, err := live.Bind(snapshot)
+	assert.Nil(t, err)
+	total, err := bound.Add(m)
+	assert.Nil(t, err)
+	ex, err = total.ExchangeTo("USD")
+	assert.Nil(t, err)
+	assert.Equal(t, "$2.00", ex.Format())
+
+	_, err = live.Add(m)
+	assert.NotNil(t, err)
+
+	err = snapshot.UpdateExchangeRatesTable()
+	assert.NotNil(t, err)
+	assert.Equal(t, "bank is a read-only snapshot: it can not be modified", err.Error())
+
+	date := time.Date(2021, time.October, 15, 0, 0, 0, 0, time.UTC)
+	snapshot.SetHistoricalExchangeRatesTable(date, money.ExchangeRatesTable{"EUR": {"USD": money.NewDecimal(3, 1)}})
+	_, err = snapshot.GetExchangeRateAt("EUR", "USD", date)
+	assert.NotNil(t, err)
+
+	snapshot.SetInverseExchangeRates(true, false)
+	_, err = snapshot.GetExchangeRate("USD", "EUR")
+	assert.NotNil(t, err)
+
+	_, err = live.Bind(money.DefaultBank)
+	assert.Nil(t, err)
+	usdOnlyBank, err := money.NewBank([]money.Currency{money.USD}, nil, nil)
+	assert.Nil(t, err)
+	_, err = live.Bind(usdOnlyBank)
+	assert.NotNil(t, err)
+	assert.Equal(t, "bank does not support EUR currency", err.Error())
+}
+
+func TestSnapshotSettingsAreFrozen(t *testing.T) {
+	bank, err := money.NewBankFromStaticExchangeRatesTable([]money.Currency{money.EUR, money.USD, money.GBP}, money.ExchangeRatesTableFromFloat(map[string]map[string]float64{
+		"EUR": {"USD": 1.25, "GBP": 0.8},
+	}))
+	assert.Nil(t, err)
+	snapshot := bank.Snapshot()
+
+	err = snapshot.SetSpread("EUR", "USD", money.Spread{BidBasisPoints: 100})
+	assert.True(t, errors.Is(err, money.ErrReadOnlyBank))
+	err = snapshot.SetDefaultSpread("EUR", money.Spread{BidBasisPoints: 100})
+	assert.True(t, errors.Is(err, money.ErrReadOnlyBank))
+	fixed, _ := snapshot.NewMoney(100, "USD")
+	err = snapshot.SetConversionFee(money.ConversionFee{Fixed: fixed})
+	assert.True(t, errors.Is(err, money.ErrReadOnlyBank))
+	err = snapshot.SetPivotCurrency("EUR")
+	assert.True(t, errors.Is(err, money.ErrReadOnlyBank))
+	err = snapshot.SetValidationRules(&money.ValidationRules{})
+	assert.True(t, errors.Is(err, money.ErrReadOnlyBank))
+	err = snapshot.SetCompactExchangeRates("EUR")
+	assert.True(t, errors.Is(err, money.ErrReadOnlyBank))
+	assert.Equal(t, "", snapshot.CompactExchangeRatesBase())
+
+	snapshot.SetRoundingMode(money.RoundDown)
+	assert.Equal(t, money.RoundHalfAwayFromZero, snapshot.GetRoundingMode())
+	snapshot.SetShortestPathTriangulation(true)
+	_, err = snapshot.GetExchangeRate("USD", "GBP")
+	assert.NotNil(t, err)
+	snapshot.SetMaxStaleness(time.Nanosecond)
+	m, _ := snapshot.NewMoney(10000, "EUR")
+	ex, err := m.ExchangeTo("USD")
+	assert.Nil(t, err)
+	assert.Equal(t, 12500, ex.Cents)
+}
+
+func TestSnapshotBeforeFirstFetch(t *testing.T) {
+	release := make(chan struct{})
+	bank, err := money.NewBankWithOptions(
+		money.WithCurrencies([]money.Currency{money.EUR, money.USD}),
+		money.WithFetcher(func() (money.ExchangeRatesTable, error) {
+			<-release
+			return nil, errors.New("provider is down")
+		}),
+		money.WithLogger(nil),
+		money.WithNonBlockingStartup(),
+	)
+	assert.Nil(t, err)
+	defer bank.Close()
+	defer close(release)
+
+	snapshot := bank.Snapshot()
+	_, err = snapshot.GetExchangeRate("EUR", "USD")
+	assert.True(t, errors.Is(err, money.ErrExchangeRatesNotAvailable))
+
+	// The currencies of the snapshot do not change with the bank
+	delete(bank.Currencies, "USD")
+	_, err = snapshot.NewMoney(100, "USD")
+	assert.Nil(t, err)
+}
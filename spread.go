@@ -0,0 +1,193 @@
+package money
+
+// Spread represents the margins, in basis points (1 basis point is 0.01%), that
+// the bank applies to the mid-market exchange rate of a currency pair. Given a
+// pair with base currency B and quote currency Q, the bid margin is applied
+// when the bank buys B (exchange from B to Q) and the ask margin when the bank
+// sells B (exchange from Q to B).
+type Spread struct {
+	// Margin applied when the bank buys the base currency.
+	BidBasisPoints int
+	// Margin applied when the bank sells the base currency.
+	AskBasisPoints int
+}
+
+// ConversionFee represents the fee that the bank charges for an exchange. See
+// Money.ExchangeWithBreakdown.
+type ConversionFee struct {
+	// Fixed fee charged for each exchange. If it is not in the target currency,
+	// it is exchanged at the mid-market exchange rate.
+	Fixed *Money
+	// Fee proportional to the exchanged monetary value, in basis points.
+	BasisPoints int
+}
+
+// ExchangeBreakdown represents the detail of an exchange of money. It is the
+// result of Money.ExchangeWithBreakdown.
+type ExchangeBreakdown struct {
+	// Money exchanged at the mid-market exchange rate.
+	MidMarket *Money
+	// Money exchanged at the exchange rate adjusted by the bank spread, that
+	// is the result of Money.ExchangeTo.
+	Exchanged *Money
+	// Difference between MidMarket and Exchanged.
+	SpreadCost *Money
+	// Conversion fee (see ConversionFee).
+	Fee *Money
+	// Exchanged less the conversion fee.
+	Net *Money
+}
+
+// SetSpread sets the spread for the pair with base currency baseIsoCode and
+// quote currency quoteIsoCode. Returns an error if the bank does not support
+// one of the two currencies or ErrReadOnlyBank if the bank is a snapshot.
+func (bank *Bank) SetSpread(baseIsoCode, quoteIsoCode string, spread Spread) error {
+	if bank.readOnly {
+		return ErrReadOnlyBank
+	}
+	_, err := bank.getCurrency(baseIsoCode)
+	if err != nil {
+		return err
+	}
+	_, err = bank.getCurrency(quoteIsoCode)
+	if err != nil {
+		return err
+	}
+	bank.mu.Lock()
+	defer bank.mu.Unlock()
+	if bank.spreads[baseIsoCode] == nil {
+		bank.spreads[baseIsoCode] = make(map[string]Spread)
+	}
+	bank.spreads[baseIsoCode][quoteIsoCode] = spread
+	return nil
+}
+
+// SetDefaultSpread sets the spread used for the pairs without their own spread
+// (see SetSpread), quoted against the currency with ISO code baseIsoCode: the
+// bid margin is applied when the bank buys the base currency (exchange from
+// the base currency) and the ask margin when the bank sells it (exchange to
+// the base currency). An exchange between two other currencies is priced as
+// an exchange to the base currency followed by an exchange from it, so both
+// margins are applied. An empty baseIsoCode prices all exchanges in this way.
+// Returns an error if the bank does not support baseIsoCode or
+// ErrReadOnlyBank if the bank is a snapshot.
+func (bank *Bank) SetDefaultSpread(baseIsoCode string, spread Spread) error {
+	if bank.readOnly {
+		return ErrReadOnlyBank
+	}
+	if baseIsoCode != "" {
+		_, err := bank.getCurrency(baseIsoCode)
+		if err != nil {
+			return err
+		}
+	}
+	bank.mu.Lock()
+	defer bank.mu.Unlock()
+	bank.defaultSpread = spread
+	bank.defaultSpreadBase = baseIsoCode
+	return nil
+}
+
+// SetConversionFee sets the fee that the bank charges for an exchange. Returns
+// an error if the fixed fee has been created by a different bank or
+// ErrReadOnlyBank if the bank is a snapshot.
+func (bank *Bank) SetConversionFee(fee ConversionFee) error {
+	if bank.readOnly {
+		return ErrReadOnlyBank
+	}
+	if fee.Fixed != nil && fee.Fixed.bank != bank {
+		return &BankMismatchError{Currency: fee.Fixed.Currency}
+	}
+	if fee.Fixed != nil {
+		// A copy, so that later changes of the caller's money do not change
+		// the fee
+		fixed := *fee.Fixed
+		fee.Fixed = &fixed
+	}
+	bank.mu.Lock()
+	defer bank.mu.Unlock()
+	bank.conversionFee = fee
+	return nil
+}
+
+// ExchangeWithBreakdown exchanges m to the currency with ISO code
+// currencyIsoCode like ExchangeTo, but it returns the breakdown of the
+// exchange: the monetary value at the mid-market exchange rate, the cost of
+// the spread and the conversion fee. Returns an error if the currencyIsoCode is
+// not supported by the current bank or if the bank is not able to exchange
+// m.Currency (or the currency of the fixed conversion fee) to currencyIsoCode.
+func (m *Money) ExchangeWithBreakdown(currencyIsoCode string) (*ExchangeBreakdown, error) {
+	breakdown := &ExchangeBreakdown{}
+	var err error
+	if m.Currency == currencyIsoCode {
+		breakdown.MidMarket, err = m.bank.NewMoney(m.Cents, m.Currency)
+		if err != nil {
+			return nil, err
+		}
+		breakdown.Exchanged, _ = m.bank.NewMoney(m.Cents, m.Currency)
+		breakdown.SpreadCost, _ = m.bank.NewMoney(0, m.Currency)
+		breakdown.Fee, _ = m.bank.NewMoney(0, m.Currency)
+		breakdown.Net, _ = m.bank.NewMoney(m.Cents, m.Currency)
+		return breakdown, nil
+	}
+
+	rate, err := m.bank.GetDecimalExchangeRate(m.Currency, currencyIsoCode)
+	if err != nil {
+		return nil, err
+	}
+	breakdown.MidMarket, err = m.ExchangeToWithDecimalRate(currencyIsoCode, rate)
+	if err != nil {
+		return nil, err
+	}
+	breakdown.Exchanged, err = m.ExchangeToWithDecimalRate(currencyIsoCode, m.bank.applySpread(m.Currency, currencyIsoCode, rate))
+	if err != nil {
+		return nil, err
+	}
+	breakdown.SpreadCost, _ = breakdown.MidMarket.Subtract(breakdown.Exchanged)
+
+	m.bank.mu.RLock()
+	fee := m.bank.conversionFee
+	m.bank.mu.RUnlock()
+	feeCents := RoundHalfAwayFromZero.round(NewDecimal(int64(breakdown.Exchanged.Cents)*int64(fee.BasisPoints), 10000))
+	if fee.Fixed != nil {
+		fixed := fee.Fixed
+		if fixed.Currency != currencyIsoCode {
+			fixedRate, err := m.bank.GetDecimalExchangeRate(fixed.Currency, currencyIsoCode)
+			if err != nil {
+				return nil, err
+			}
+			fixed, err = fixed.ExchangeToWithDecimalRate(currencyIsoCode, fixedRate)
+			if err != nil {
+				return nil, err
+			}
+		}
+		feeCents += fixed.Cents
+	}
+	breakdown.Fee, _ = m.bank.NewMoney(feeCents, currencyIsoCode)
+	breakdown.Net, _ = breakdown.Exchanged.Subtract(breakdown.Fee)
+	return breakdown, nil
+}
+
+// Private functions
+
+// applySpread returns the mid-market rate to exchange fromCurrencyIsoCode to
+// toCurrencyIsoCode adjusted by the bank spread.
+func (bank *Bank) applySpread(fromCurrencyIsoCode, toCurrencyIsoCode string, rate Decimal) Decimal {
+	bank.mu.RLock()
+	defer bank.mu.RUnlock()
+	if spread, found := bank.spreads[fromCurrencyIsoCode][toCurrencyIsoCode]; found {
+		return rate.Mul(NewDecimal(int64(10000-spread.BidBasisPoints), 10000))
+	}
+	if spread, found := bank.spreads[toCurrencyIsoCode][fromCurrencyIsoCode]; found {
+		return rate.Mul(NewDecimal(10000, int64(10000+spread.AskBasisPoints)))
+	}
+	bid := NewDecimal(int64(10000-bank.defaultSpread.BidBasisPoints), 10000)
+	ask := NewDecimal(10000, int64(10000+bank.defaultSpread.AskBasisPoints))
+	switch bank.defaultSpreadBase {
+	case fromCurrencyIsoCode:
+		return rate.Mul(bid)
+	case toCurrencyIsoCode:
+		return rate.Mul(ask)
+	}
+	return rate.Mul(bid).Mul(ask)
+}
@@ -0,0 +1,157 @@
+package money_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pioz/money"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSpread(t *testing.T) {
+	bank, err := money.NewBankFromStaticExchangeRatesTable([]money.Currency{money.EUR, money.USD, money.GBP}, money.ExchangeRatesTableFromFloat(map[string]map[string]float64{
+		"EUR": {"USD": 1.25, "GBP": 0.8},
+		"USD": {"EUR": 0.8},
+		"GBP": {"EUR": 1.25},
+	}))
+	assert.Nil(t, err)
+	eur, err := bank.NewMoney(10000, "EUR")
+	assert.Nil(t, err)
+	usd, err := bank.NewMoney(10000, "USD")
+	assert.Nil(t, err)
+	gbp, err := bank.NewMoney(10000, "GBP")
+	assert.Nil(t, err)
+
+	err = bank.SetSpread("EUR", "XLN", money.Spread{})
+	assert.NotNil(t, err)
+	assert.Equal(t, "bank does not support XLN currency", err.Error())
+
+	err = bank.SetSpread("EUR", "USD", money.Spread{BidBasisPoints: 100, AskBasisPoints: 25})
+	assert.Nil(t, err)
+
+	ex, err := eur.ExchangeTo("USD")
+	assert.Nil(t, err)
+	assert.Equal(t, 12375, ex.Cents)
+
+	ex, err = usd.ExchangeTo("EUR")
+	assert.Nil(t, err)
+	assert.Equal(t, 7980, ex.Cents)
+
+	ex, err = gbp.ExchangeTo("EUR")
+	assert.Nil(t, err)
+	assert.Equal(t, 12500, ex.Cents)
+
+	err = bank.SetDefaultSpread("XLN", money.Spread{})
+	assert.NotNil(t, err)
+	assert.Equal(t, "bank does not support XLN currency", err.Error())
+
+	// The default spread is quoted against EUR
+	err = bank.SetDefaultSpread("EUR", money.Spread{BidBasisPoints: 200, AskBasisPoints: 100})
+	assert.Nil(t, err)
+	ex, err = gbp.ExchangeTo("EUR")
+	assert.Nil(t, err)
+	assert.Equal(t, 12376, ex.Cents)
+	ex, err = eur.ExchangeTo("GBP")
+	assert.Nil(t, err)
+	assert.Equal(t, 7840, ex.Cents)
+
+	// Without base currency both margins are applied
+	err = bank.SetDefaultSpread("", money.Spread{BidBasisPoints: 200, AskBasisPoints: 100})
+	assert.Nil(t, err)
+	ex, err = gbp.ExchangeTo("EUR")
+	assert.Nil(t, err)
+	assert.Equal(t, 12129, ex.Cents)
+
+	ex, err = eur.ExchangeTo("USD")
+	assert.Nil(t, err)
+	assert.Equal(t, 12375, ex.Cents)
+
+	rate, err := bank.GetExchangeRate("EUR", "USD")
+	assert.Nil(t, err)
+	assert.Equal(t, 1.25, rate)
+}
+
+func TestSpreadDoesNotAffectOperations(t *testing.T) {
+	bank, err := money.NewBankFromStaticExchangeRatesTable([]money.Currency{money.EUR, money.USD}, money.ExchangeRatesTableFromFloat(map[string]map[string]float64{
+		"EUR": {"USD": 1.25},
+		"USD": {"EUR": 0.8},
+	}))
+	assert.Nil(t, err)
+	err = bank.SetSpread("EUR", "USD", money.Spread{BidBasisPoints: 100, AskBasisPoints: 100})
+	assert.Nil(t, err)
+	eur, _ := bank.NewMoney(10000, "EUR")
+	usd, _ := bank.NewMoney(12500, "USD")
+
+	// Operations and comparisons use the mid-market exchange rate
+	equals, err := eur.Equals(usd)
+	assert.Nil(t, err)
+	assert.True(t, equals)
+	equals, err = usd.Equals(eur)
+	assert.Nil(t, err)
+	assert.True(t, equals)
+	sum, err := eur.Add(usd)
+	assert.Nil(t, err)
+	assert.Equal(t, 20000, sum.Cents)
+
+	// while the exchanges apply the spread, also at a past date
+	date := time.Date(2021, time.October, 15, 0, 0, 0, 0, time.UTC)
+	bank.SetHistoricalExchangeRatesTable(date, money.ExchangeRatesTableFromFloat(map[string]map[string]float64{"EUR": {"USD": 1.25}}))
+	ex, err := eur.ExchangeTo("USD")
+	assert.Nil(t, err)
+	assert.Equal(t, 12375, ex.Cents)
+	ex, err = eur.ExchangeToAt("USD", date)
+	assert.Nil(t, err)
+	assert.Equal(t, 12375, ex.Cents)
+}
+
+func TestExchangeWithBreakdown(t *testing.T) {
+	bank, err := money.NewBankFromStaticExchangeRatesTable([]money.Currency{money.EUR, money.USD, money.GBP}, money.ExchangeRatesTableFromFloat(map[string]map[string]float64{
+		"EUR": {"USD": 1.25},
+		"GBP": {"USD": 2},
+	}))
+	assert.Nil(t, err)
+	err = bank.SetSpread("EUR", "USD", money.Spread{BidBasisPoints: 100})
+	assert.Nil(t, err)
+
+	fixed, err := money.NewMoney(100, "GBP")
+	assert.Nil(t, err)
+	err = bank.SetConversionFee(money.ConversionFee{Fixed: fixed})
+	assert.NotNil(t, err)
+	assert.Equal(t, "currencies have different banks: operation between currencies can be done only between currencies of the same bank", err.Error())
+
+	fixed, err = bank.NewMoney(100, "GBP")
+	assert.Nil(t, err)
+	err = bank.SetConversionFee(money.ConversionFee{Fixed: fixed, BasisPoints: 50})
+	assert.Nil(t, err)
+
+	m, err := bank.NewMoney(10000, "EUR")
+	assert.Nil(t, err)
+	breakdown, err := m.ExchangeWithBreakdown("USD")
+	assert.Nil(t, err)
+	assert.Equal(t, "$125.00", breakdown.MidMarket.Format())
+	assert.Equal(t, "$123.75", breakdown.Exchanged.Format())
+	assert.Equal(t, "$1.25", breakdown.SpreadCost.Format())
+	assert.Equal(t, "$2.62", breakdown.Fee.Format())
+	assert.Equal(t, "$121.13", breakdown.Net.Format())
+
+	// The bank keeps a copy of the fixed fee
+	fixed.Cents = 1000
+	breakdown, err = m.ExchangeWithBreakdown("USD")
+	assert.Nil(t, err)
+	assert.Equal(t, "$2.62", breakdown.Fee.Format())
+
+	breakdown, err = m.ExchangeWithBreakdown("EUR")
+	assert.Nil(t, err)
+	assert.Equal(t, "€100,00", breakdown.Net.Format())
+	assert.True(t, breakdown.Fee.IsZero())
+
+	_, err = m.ExchangeWithBreakdown("GBP")
+	assert.NotNil(t, err)
+	assert.Equal(t, "bank does not support exchange from EUR to GBP", err.Error())
+
+	usd, err := bank.NewMoney(10000, "USD")
+	assert.Nil(t, err)
+	_, err = usd.ExchangeWithBreakdown("EUR")
+	assert.NotNil(t, err)
+	assert.Equal(t, "bank does not support exchange from USD to EUR", err.Error())
+}
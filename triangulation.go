@@ -0,0 +1,132 @@
+package money
+
+import "sort"
+
+// SetPivotCurrency sets the currency with ISO code currencyIsoCode as pivot
+// currency of the bank. When the exchange rates table does not have the
+// exchange rate from a currency X to a currency Y, the bank derives it through
+// the pivot currency P as X→P * P→Y. An empty currencyIsoCode disables the
+// pivot currency. Returns an error if the currency is not supported by the
+// bank or ErrReadOnlyBank if the bank is a snapshot.
+func (bank *Bank) SetPivotCurrency(currencyIsoCode string) error {
+	if bank.readOnly {
+		return ErrReadOnlyBank
+	}
+	if currencyIsoCode != "" {
+		_, err := bank.getCurrency(currencyIsoCode)
+		if err != nil {
+			return err
+		}
+	}
+	bank.mu.Lock()
+	defer bank.mu.Unlock()
+	bank.pivotCurrencyIsoCode = currencyIsoCode
+	return nil
+}
+
+// SetShortestPathTriangulation enables or disables the shortest path
+// triangulation. When enabled and an exchange rate can not be found neither
+// in the exchange rates table nor through the pivot currency, the bank derives
+// it by multiplying the exchange rates along the path with the fewest
+// exchanges in the graph of the exchange rates table. It has no effect if the
+// bank is a snapshot.
+func (bank *Bank) SetShortestPathTriangulation(enabled bool) {
+	if bank.readOnly {
+		return
+	}
+	bank.mu.Lock()
+	defer bank.mu.Unlock()
+	bank.shortestPathTriangulation = enabled
+}
+
+// GetExchangeRatePath works like GetDecimalExchangeRate, but it also returns the
+// currency ISO codes of the path used to get the exchange rate: for example
+// [EUR USD] if the exchange rate comes straight from the exchange rates table,
+// or [EUR USD JPY] if it has been triangulated through USD.
+func (bank *Bank) GetExchangeRatePath(fromCurrencyIsoCode, toCurrencyIsoCode string) (Decimal, []string, error) {
+	if fromCurrencyIsoCode == toCurrencyIsoCode {
+		return NewDecimal(1, 1), []string{fromCurrencyIsoCode}, nil
+	}
+	bank.mu.RLock()
+	defer bank.mu.RUnlock()
+	if bank.nonBlockingStartup && bank.exchangeRatesUpdatedAt.IsZero() {
+		return Decimal{}, nil, &RateUnavailableError{From: fromCurrencyIsoCode, To: toCurrencyIsoCode, Err: ErrExchangeRatesNotAvailable}
+	}
+	if bank.isStale() {
+		return Decimal{}, nil, &RateUnavailableError{From: fromCurrencyIsoCode, To: toCurrencyIsoCode, Err: ErrStaleExchangeRates}
+	}
+	rate, path := bank.findExchangeRate(bank.ExchangeRatesTable, fromCurrencyIsoCode, toCurrencyIsoCode)
+	if path == nil {
+		return Decimal{}, nil, &RateUnavailableError{From: fromCurrencyIsoCode, To: toCurrencyIsoCode}
+	}
+	return rate, path, nil
+}
+
+// Private functions
+
+// findExchangeRate looks for the exchange rate in table, then through the base
+// currency of the compact table, then through the pivot currency and finally
+// with the shortest path triangulation. Returns a nil path if the exchange rate
+// can not be found. The caller must hold bank.mu.
+func (bank *Bank) findExchangeRate(table ExchangeRatesTable, fromCurrencyIsoCode, toCurrencyIsoCode string) (Decimal, []string) {
+	rate := table[fromCurrencyIsoCode][toCurrencyIsoCode]
+	if !rate.IsZero() {
+		return rate, []string{fromCurrencyIsoCode, toCurrencyIsoCode}
+	}
+	rate, path := bank.compactExchangeRate(table, fromCurrencyIsoCode, toCurrencyIsoCode)
+	if path != nil {
+		return rate, path
+	}
+	pivot := bank.pivotCurrencyIsoCode
+	if pivot != "" && pivot != fromCurrencyIsoCode && pivot != toCurrencyIsoCode {
+		rate = table[fromCurrencyIsoCode][pivot].Mul(table[pivot][toCurrencyIsoCode])
+		if !rate.IsZero() {
+			return rate, []string{fromCurrencyIsoCode, pivot, toCurrencyIsoCode}
+		}
+	}
+	if bank.shortestPathTriangulation {
+		path := shortestExchangePath(table, fromCurrencyIsoCode, toCurrencyIsoCode)
+		if path != nil {
+			rate = NewDecimal(1, 1)
+			for i := 1; i < len(path); i++ {
+				rate = rate.Mul(table[path[i-1]][path[i]])
+			}
+			return rate, path
+		}
+	}
+	return Decimal{}, nil
+}
+
+// shortestExchangePath returns the path with the fewest exchanges from
+// fromCurrencyIsoCode to toCurrencyIsoCode using a breadth-first search over
+// the exchange rates of table. Returns nil if there is no path.
+func shortestExchangePath(table ExchangeRatesTable, fromCurrencyIsoCode, toCurrencyIsoCode string) []string {
+	previous := map[string]string{fromCurrencyIsoCode: ""}
+	queue := []string{fromCurrencyIsoCode}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		neighbours := make([]string, 0, len(table[current]))
+		for isoCode, rate := range table[current] {
+			if !rate.IsZero() {
+				neighbours = append(neighbours, isoCode)
+			}
+		}
+		sort.Strings(neighbours)
+		for _, isoCode := range neighbours {
+			if _, visited := previous[isoCode]; visited {
+				continue
+			}
+			previous[isoCode] = current
+			if isoCode == toCurrencyIsoCode {
+				path := []string{isoCode}
+				for step := current; step != ""; step = previous[step] {
+					path = append([]string{step}, path...)
+				}
+				return path
+			}
+			queue = append(queue, isoCode)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package money_test
+
+import (
+	"testing"
+
+	"github.com/pioz/money"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPivotCurrency(t *testing.T) {
+	bank, err := money.NewBankFromStaticExchangeRatesTable([]money.Currency{money.EUR, money.USD, money.JPY}, money.ExchangeRatesTableFromFloat(map[string]map[string]float64{
+		"EUR": {"USD": 1.25},
+		"USD": {"JPY": 100},
+	}))
+	assert.Nil(t, err)
+
+	_, err = bank.GetExchangeRate("EUR", "JPY")
+	assert.NotNil(t, err)
+	assert.Equal(t, "bank does not support exchange from EUR to JPY", err.Error())
+
+	err = bank.SetPivotCurrency("XLN")
+	assert.NotNil(t, err)
+	assert.Equal(t, "bank does not support XLN currency", err.Error())
+
+	err = bank.SetPivotCurrency("USD")
+	assert.Nil(t, err)
+
+	rate, path, err := bank.GetExchangeRatePath("EUR", "JPY")
+	assert.Nil(t, err)
+	assert.Equal(t, "125", rate.String())
+	assert.Equal(t, []string{"EUR", "USD", "JPY"}, path)
+
+	rate, path, err = bank.GetExchangeRatePath("EUR", "USD")
+	assert.Nil(t, err)
+	assert.Equal(t, "1.25", rate.String())
+	assert.Equal(t, []string{"EUR", "USD"}, path)
+
+	_, _, err = bank.GetExchangeRatePath("JPY", "EUR")
+	assert.NotNil(t, err)
+	assert.Equal(t, "bank does not support exchange from JPY to EUR", err.Error())
+
+	m, err := bank.NewMoney(100, "EUR")
+	assert.Nil(t, err)
+	ex, err := m.ExchangeTo("JPY")
+	assert.Nil(t, err)
+	assert.Equal(t, 125, ex.Cents)
+
+	err = bank.SetPivotCurrency("")
+	assert.Nil(t, err)
+	_, err = bank.GetExchangeRate("EUR", "JPY")
+	assert.NotNil(t, err)
+}
+
+func TestShortestPathTriangulation(t *testing.T) {
+	bank, err := money.NewBankFromStaticExchangeRatesTable([]money.Currency{money.EUR, money.USD, money.GBP, money.JPY}, money.ExchangeRatesTableFromFloat(map[string]map[string]float64{
+		"EUR": {"GBP": 0.5},
+		"GBP": {"USD": 2},
+		"USD": {"JPY": 100},
+	}))
+	assert.Nil(t, err)
+
+	_, err = bank.GetExchangeRate("EUR", "JPY")
+	assert.NotNil(t, err)
+
+	bank.SetShortestPathTriangulation(true)
+
+	rate, path, err := bank.GetExchangeRatePath("EUR", "JPY")
+	assert.Nil(t, err)
+	assert.Equal(t, "100", rate.String())
+	assert.Equal(t, []string{"EUR", "GBP", "USD", "JPY"}, path)
+
+	rate, path, err = bank.GetExchangeRatePath("GBP", "JPY")
+	assert.Nil(t, err)
+	assert.Equal(t, "200", rate.String())
+	assert.Equal(t, []string{"GBP", "USD", "JPY"}, path)
+
+	_, _, err = bank.GetExchangeRatePath("JPY", "EUR")
+	assert.NotNil(t, err)
+	assert.Equal(t, "bank does not support exchange from JPY to EUR", err.Error())
+}
@@ -0,0 +1,182 @@
+package money
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Names of the rules violated by an exchange rates table (see Violation).
+const (
+	RulePositivity = "positivity"
+	RuleDeviation  = "deviation"
+	RuleReciprocal = "reciprocal"
+	RuleCoverage   = "coverage"
+)
+
+// ValidationRules are the rules that a new exchange rates table must respect
+// to be applied by the bank (see Bank.SetValidationRules). The exchange rates
+// must always be positive, while the other rules are disabled by their zero
+// value. Only the exchange rates between currencies supported by the bank are
+// validated.
+type ValidationRules struct {
+	// Maximum relative change of an exchange rate from the current one, for
+	// example 0.1 rejects the exchange rates that differ more than 10% from
+	// the exchange rates of the bank.
+	MaxDeviation float64
+	// Maximum distance from 1 of the product A→B * B→A, for the pairs with
+	// both exchange rates in the table. A violation is reported once for each
+	// pair, with From < To.
+	ReciprocalTolerance float64
+	// ISO codes of the currencies that the table must cover: for each
+	// currency, the table must have the exchange rates to all the other
+	// required currencies (see ExchangeRatesTable.FindCoverageGaps).
+	RequiredCurrencies []string
+}
+
+// Violation describes an exchange rate that violates a validation rule.
+type Violation struct {
+	// Name of the violated rule, for example RuleDeviation.
+	Rule string
+	// ISO code of the currency to convert from.
+	From string
+	// ISO code of the currency to convert to.
+	To string
+	// New exchange rate, zero if it is missing.
+	Rate Decimal
+	// Current exchange rate of the bank (RuleDeviation) or exchange rate To→From
+	// (RuleReciprocal), otherwise zero.
+	Other Decimal
+}
+
+func (v Violation) String() string {
+	switch v.Rule {
+	case RulePositivity:
+		return fmt.Sprintf("%s→%s rate %s is not positive", v.From, v.To, v.Rate)
+	case RuleDeviation:
+		return fmt.Sprintf("%s→%s rate %s deviates too much from %s", v.From, v.To, v.Rate, v.Other)
+	case RuleReciprocal:
+		return fmt.Sprintf("%s→%s rate %s is not reciprocal of %s→%s rate %s", v.From, v.To, v.Rate, v.To, v.From, v.Other)
+	case RuleCoverage:
+		return fmt.Sprintf("%s→%s rate is missing", v.From, v.To)
+	}
+	return fmt.Sprintf("%s→%s rate %s violates %s rule", v.From, v.To, v.Rate, v.Rule)
+}
+
+// ValidationError is returned when the bank rejects an exchange rates table
+// because it violates the validation rules. The bank keeps its current
+// exchange rates.
+type ValidationError struct {
+	// Source of the rejected table: RateSourceFetch, RateSourceCache or
+	// RateSourceSeed.
+	Source     string
+	Violations []Violation
+}
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, len(e.Violations))
+	for i, violation := range e.Violations {
+		messages[i] = violation.String()
+	}
+	return fmt.Sprintf("invalid exchange rates table from %s: %s", e.Source, strings.Join(messages, "; "))
+}
+
+// Is returns true if target is ErrInvalidExchangeRatesTable.
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrInvalidExchangeRatesTable
+}
+
+// SetValidationRules sets the rules used to validate each new exchange rates
+// table, fetched or read from the cache, before it is applied. A rejected
+// fetched table is reported with a ValidationError to the logger and to the
+// fetch error handlers, while a rejected cached table is ignored as if the
+// cache could not be read. A nil rules, the default, disables the validation.
+// Returns an error if a tolerance is NaN or infinite, if a required currency is
+// not supported by the bank or ErrReadOnlyBank if the bank is a snapshot.
+func (bank *Bank) SetValidationRules(rules *ValidationRules) error {
+	if bank.readOnly {
+		return ErrReadOnlyBank
+	}
+	if rules != nil {
+		for _, tolerance := range []float64{rules.MaxDeviation, rules.ReciprocalTolerance} {
+			_, err := DecimalFromFloat(tolerance)
+			if err != nil {
+				return err
+			}
+		}
+		for _, currencyIsoCode := range rules.RequiredCurrencies {
+			_, err := bank.getCurrency(currencyIsoCode)
+			if err != nil {
+				return err
+			}
+		}
+		rulesCopy := *rules
+		rulesCopy.RequiredCurrencies = append([]string(nil), rules.RequiredCurrencies...)
+		rules = &rulesCopy
+	}
+	bank.mu.Lock()
+	defer bank.mu.Unlock()
+	bank.validationRules = rules
+	return nil
+}
+
+// Private functions
+
+// validateExchangeRatesTable returns the violations of the validation rules in
+// table, sorted by currency ISO codes. The caller must hold bank.mu.
+func (bank *Bank) validateExchangeRatesTable(table ExchangeRatesTable) []Violation {
+	rules := bank.validationRules
+	if rules == nil {
+		return nil
+	}
+	maxDeviation := NewDecimalFromFloat(rules.MaxDeviation)
+	reciprocalTolerance := NewDecimalFromFloat(rules.ReciprocalTolerance)
+	one := NewDecimal(1, 1)
+	currentTable := bank.ExchangeRatesTable
+	if base := bank.baseCurrencyIsoCode; base != "" {
+		// The bank uses also the cross rates derived from the base currency
+		currentTable = bank.expandedExchangeRatesTable()
+		table = table.Merge(ExchangeRatesTableFromBase(base, table.baseRates(base)), KeepExisting)
+	}
+
+	violations := make([]Violation, 0)
+	bank.eachSupportedExchangeRate(table, func(fromCurrencyIsoCode, toCurrencyIsoCode string, rate Decimal) {
+		if rate.Sign() <= 0 {
+			violations = append(violations, Violation{Rule: RulePositivity, From: fromCurrencyIsoCode, To: toCurrencyIsoCode, Rate: rate})
+			return
+		}
+		current := currentTable[fromCurrencyIsoCode][toCurrencyIsoCode]
+		if rules.MaxDeviation > 0 && current.Sign() > 0 && absDecimal(rate.Sub(current)).Quo(current).Cmp(maxDeviation) > 0 {
+			violations = append(violations, Violation{Rule: RuleDeviation, From: fromCurrencyIsoCode, To: toCurrencyIsoCode, Rate: rate, Other: current})
+		}
+		inverse := table[toCurrencyIsoCode][fromCurrencyIsoCode]
+		// Each pair is checked once
+		if rules.ReciprocalTolerance > 0 && fromCurrencyIsoCode < toCurrencyIsoCode && inverse.Sign() > 0 && absDecimal(rate.Mul(inverse).Sub(one)).Cmp(reciprocalTolerance) > 0 {
+			violations = append(violations, Violation{Rule: RuleReciprocal, From: fromCurrencyIsoCode, To: toCurrencyIsoCode, Rate: rate, Other: inverse})
+		}
+	})
+	for _, gap := range table.FindCoverageGaps(rules.RequiredCurrencies) {
+		// A published rate that is not positive has already been reported
+		if _, found := table[gap.From][gap.To]; found {
+			continue
+		}
+		violations = append(violations, Violation{Rule: RuleCoverage, From: gap.From, To: gap.To})
+	}
+	sort.Slice(violations, func(i, j int) bool {
+		if violations[i].From != violations[j].From {
+			return violations[i].From < violations[j].From
+		}
+		if violations[i].To != violations[j].To {
+			return violations[i].To < violations[j].To
+		}
+		return violations[i].Rule < violations[j].Rule
+	})
+	return violations
+}
+
+func absDecimal(d Decimal) Decimal {
+	if d.Sign() < 0 {
+		return Decimal{}.Sub(d)
+	}
+	return d
+}
@@ -0,0 +1,142 @@
+package money_test
+
+import (
+	"errors"
+	"math"
+	"os"
+	"testing"
+
+	"github.com/pioz/money"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetValidationRules(t *testing.T) {
+	table := money.ExchangeRatesTableFromFloat(map[string]map[string]float64{
+		"EUR": {"USD": 1.2, "JPY": 130},
+		"USD": {"EUR": 0.8, "JPY": 110},
+	})
+	var handled []error
+	bank, err := money.NewBank([]money.Currency{money.EUR, money.USD, money.JPY}, func() (money.ExchangeRatesTable, error) {
+		return table, nil
+	}, nil)
+	assert.Nil(t, err)
+	bank.SetLogger(nil)
+	bank.OnFetchError(func(err error) {
+		handled = append(handled, err)
+	})
+	err = bank.SetValidationRules(&money.ValidationRules{
+		MaxDeviation:        0.1,
+		ReciprocalTolerance: 0.05,
+		RequiredCurrencies:  []string{"EUR", "USD"},
+	})
+	assert.Nil(t, err)
+
+	// Valid update
+	table = money.ExchangeRatesTableFromFloat(map[string]map[string]float64{
+		"EUR": {"USD": 1.25, "JPY": 131},
+		"USD": {"EUR": 0.8},
+	})
+	assert.Nil(t, bank.UpdateExchangeRatesTable())
+	rate, _ := bank.GetExchangeRate("EUR", "USD")
+	assert.Equal(t, 1.25, rate)
+
+	// Invalid update
+	table = money.ExchangeRatesTableFromFloat(map[string]map[string]float64{
+		"EUR": {"USD": 125, "JPY": -1},
+		"USD": {"JPY": 110},
+	})
+	err = bank.UpdateExchangeRatesTable()
+	assert.True(t, errors.Is(err, money.ErrInvalidExchangeRatesTable))
+	var validationErr *money.ValidationError
+	assert.True(t, errors.As(err, &validationErr))
+	assert.Equal(t, money.RateSourceFetch, validationErr.Source)
+	assert.Equal(t, []money.Violation{
+		{Rule: money.RulePositivity, From: "EUR", To: "JPY", Rate: money.NewDecimal(-1, 1)},
+		{Rule: money.RuleDeviation, From: "EUR", To: "USD", Rate: money.NewDecimal(125, 1), Other: money.MustParseDecimal("1.25")},
+		{Rule: money.RuleCoverage, From: "USD", To: "EUR"},
+	}, validationErr.Violations)
+	assert.EqualError(t, err, "invalid exchange rates table from fetch: EUR→JPY rate -1 is not positive; EUR→USD rate 125 deviates too much from 1.25; USD→EUR rate is missing")
+	assert.Equal(t, []error{err}, handled)
+
+	// The old exchange rates are kept
+	rate, _ = bank.GetExchangeRate("EUR", "USD")
+	assert.Equal(t, 1.25, rate)
+	rate, _ = bank.GetExchangeRate("EUR", "JPY")
+	assert.Equal(t, 131.0, rate)
+
+	// A required exchange rate that is not positive is reported once
+	table = money.ExchangeRatesTableFromFloat(map[string]map[string]float64{
+		"EUR": {"USD": 0},
+		"USD": {"EUR": 0.8},
+	})
+	err = bank.UpdateExchangeRatesTable()
+	assert.EqualError(t, err, "invalid exchange rates table from fetch: EUR→USD rate 0 is not positive")
+
+	// Not reciprocal exchange rates
+	table = money.ExchangeRatesTableFromFloat(map[string]map[string]float64{
+		"EUR": {"USD": 1.3},
+		"USD": {"EUR": 0.85},
+	})
+	err = bank.UpdateExchangeRatesTable()
+	assert.EqualError(t, err, "invalid exchange rates table from fetch: EUR→USD rate 1.3 is not reciprocal of USD→EUR rate 0.85")
+
+	// Disable the validation
+	assert.Nil(t, bank.SetValidationRules(nil))
+	assert.Nil(t, bank.UpdateExchangeRatesTable())
+	rate, _ = bank.GetExchangeRate("EUR", "USD")
+	assert.Equal(t, 1.3, rate)
+
+	err = bank.SetValidationRules(&money.ValidationRules{RequiredCurrencies: []string{"GBP"}})
+	assert.True(t, errors.Is(err, money.ErrUnsupportedCurrency))
+}
+
+func TestSetValidationRulesRejectedCache(t *testing.T) {
+	fileCache := money.ExchangeRatesTableFileCache{FilePath: "/tmp/go-money-rejected-cache"}
+	defer os.RemoveAll(fileCache.FilePath)
+	err := fileCache.Write(money.ExchangeRatesTableFromFloat(map[string]map[string]float64{"EUR": {"USD": 0}}))
+	assert.Nil(t, err)
+
+	logger := &recordLogger{}
+	bank, err := money.NewBankWithOptions(
+		money.WithCurrencies([]money.Currency{money.EUR, money.USD}),
+		money.WithFetcher(func() (money.ExchangeRatesTable, error) {
+			return money.ExchangeRatesTableFromFloat(map[string]map[string]float64{"EUR": {"USD": 1.2}}), nil
+		}),
+		money.WithCache(fileCache),
+		money.WithLogger(logger),
+		money.WithValidationRules(money.ValidationRules{}),
+	)
+	assert.Nil(t, err)
+	// The rejected cache is ignored and the table is fetched immediately
+	rate, err := bank.GetExchangeRate("EUR", "USD")
+	assert.Nil(t, err)
+	assert.Equal(t, 1.2, rate)
+	assert.Equal(t, "WARN", logger.events[0].level)
+	assert.Equal(t, "exchange rates table cache rejected", logger.events[0].msg)
+}
+
+func TestWithValidationRulesInvalidSeed(t *testing.T) {
+	bank, err := money.NewBankWithOptions(
+		money.WithCurrencies([]money.Currency{money.EUR, money.USD}),
+		money.WithSeedExchangeRatesTable(money.ExchangeRatesTableFromFloat(map[string]map[string]float64{"EUR": {"USD": -1.2}})),
+		money.WithValidationRules(money.ValidationRules{}),
+	)
+	assert.Nil(t, bank)
+	assert.EqualError(t, err, "invalid exchange rates table from seed: EUR→USD rate -1.2 is not positive")
+}
+
+func TestSetValidationRulesNonFiniteRates(t *testing.T) {
+	bank, err := money.NewBankWithOptions(
+		money.WithCurrencies([]money.Currency{money.EUR, money.USD}),
+		money.WithFetcher(func() (money.ExchangeRatesTable, error) {
+			return money.ExchangeRatesTableFromFloat(map[string]map[string]float64{"EUR": {"USD": math.NaN()}}), nil
+		}),
+		money.WithLogger(nil),
+		money.WithValidationRules(money.ValidationRules{}),
+	)
+	assert.True(t, errors.Is(err, money.ErrInvalidExchangeRatesTable))
+	assert.EqualError(t, err, "invalid exchange rates table from fetch: EUR→USD rate 0 is not positive")
+
+	err = bank.SetValidationRules(&money.ValidationRules{MaxDeviation: math.Inf(1)})
+	assert.EqualError(t, err, "invalid decimal +Inf")
+}